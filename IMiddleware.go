@@ -1,10 +1,13 @@
 package fw
 
 import (
+	"fmt"
 	"github.com/linxlib/config"
 	"github.com/linxlib/fw/attribute"
+	"github.com/linxlib/fw/binding"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -13,14 +16,17 @@ type SlotType = string
 
 // MiddlewareContext represents the context in which a middleware is executed.
 type MiddlewareContext struct {
-	ControllerName string
+	ControllerName string // the name of the middleware itself for global middlewares, see Controller
 	MethodName     string
+	Controller     string // the controller of the route for all middlewares, "Handle" for routes registered by Handle
+	Handler        string // the method of the route, empty if the route has no method, e.g. auto OPTIONS
 	Location       SlotType
 	param          map[SlotType]string
 	rValue         map[SlotType]reflect.Value
 	paramValues    url.Values
 	rawParams      string
-	Ignored        bool
+	params         any
+	Ignored        bool // the route ignores this middleware by @Ignore, Execute should return Next
 	Next           HandlerFunc
 }
 
@@ -54,6 +60,90 @@ func (m *MiddlewareContext) GetRValue() reflect.Value {
 	}
 }
 
+// Params returns the typed params decoded from attribute value, see IParams.
+// it is nil if the middleware does not implement IParams
+func (m *MiddlewareContext) Params() any {
+	return m.params
+}
+
+// GetParams returns the typed params of middleware context as *T, nil if they are not *T
+//
+//	p := fw.GetParams[RateLimitParams](ctx)
+func GetParams[T any](m *MiddlewareContext) *T {
+	p, _ := m.params.(*T)
+	return p
+}
+
+// decodeParams decodes attribute value into the params struct declared by mid
+func (m *MiddlewareContext) decodeParams(mid any) error {
+	p, ok := mid.(IParams)
+	if !ok {
+		return nil
+	}
+	values, err := url.ParseQuery(m.rawParams)
+	if err != nil {
+		return fmt.Errorf("malformed params: %w", err)
+	}
+	params := p.Params()
+	if err := matchParamKeys(params, values); err != nil {
+		return err
+	}
+	if err := binding.MapForm(params, values); err != nil {
+		return err
+	}
+	m.params = params
+	return nil
+}
+
+// matchParamKeys renames keys of values to the names of fields without `form` tag (case-insensitive),
+// it returns an error for keys which match no field
+func matchParamKeys(params any, values url.Values) error {
+	t := reflect.TypeOf(params)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		found := false
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				found = true
+				if key != name {
+					values[name] = append(values[name], values[key]...)
+					delete(values, key)
+				}
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown param %q, should be one of %s", key, strings.Join(names, ","))
+		}
+	}
+	return nil
+}
+
 // GetRawParams returns the raw parameters of the middleware context.
 func (m *MiddlewareContext) GetRawParams() string {
 	return m.rawParams
@@ -66,7 +156,8 @@ func (m *MiddlewareContext) GetRawParams() string {
 // and next is the next handler function in the chain.
 // Returns a pointer to a MiddlewareContext instance.
 func newMiddlewareContext(ctlName, methodName string, location SlotType, param string, next HandlerFunc) *MiddlewareContext {
-	m := &MiddlewareContext{ControllerName: ctlName, MethodName: methodName, Location: location, Next: next}
+	m := &MiddlewareContext{ControllerName: ctlName, MethodName: methodName, Controller: ctlName, Handler: methodName,
+		Location: location, Next: abortable(next)}
 	m.param = make(map[SlotType]string)
 	m.rValue = make(map[SlotType]reflect.Value)
 	m.param[location] = param
@@ -79,6 +170,19 @@ func newMiddlewareContext(ctlName, methodName string, location SlotType, param s
 	return m
 }
 
+// abortable wraps next so that it will be skipped once the context has been aborted
+func abortable(next HandlerFunc) HandlerFunc {
+	if next == nil {
+		return nil
+	}
+	return func(c *Context) {
+		if c.IsAborted() {
+			return
+		}
+		next(c)
+	}
+}
+
 // GetParam returns the value associated with key from middleware context's
 // parameters.
 //
@@ -101,6 +205,36 @@ type IMiddlewareBase interface {
 	GetSlot() SlotType
 }
 
+// IPriority can be implemented by a middleware to change its position in the chain.
+//
+// a route is handled by global middlewares, then controller middlewares, then method middlewares and the method itself.
+// global middlewares run in the order of registration, controller and method middlewares run in the reverse order
+// of annotation (the last annotated one is the outermost), unless a smaller Priority makes it run earlier (outer),
+// e.g. -10 for auth and 10 for logging. the default is 0.
+//
+//	// @Auth
+//	// @Log
+//	// @GET /users
+//	func (u *UserController) List() {} // Log > Auth > List
+type IPriority interface {
+	Priority() int
+}
+
+// IParams can be implemented by a middleware to declare the params of its attribute.
+// Params returns a pointer to a new params struct, e.g. `@RateLimit rate=10&burst=20` can be decoded into
+//
+//	type RateLimitParams struct {
+//		Rate  int `validate:"required|min:1"`
+//		Burst int `form:"burst,default=1"`
+//	}
+//
+// the attribute value is decoded with form mapping (keys match field names case-insensitively, or `form` tags)
+// and validated when routes are registered, malformed annotations stop the server from starting.
+// use GetParams or MiddlewareContext.Params to get them in Execute and Router.
+type IParams interface {
+	Params() any
+}
+
 // IInitOnce is an interface that will be called only once
 type IInitOnce interface {
 	DoInitOnce()
@@ -0,0 +1,234 @@
+package fw
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/linxlib/fw/internal/json"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// AccessLogOption configures the access log middleware, it lives under `logger.accessLog`
+type AccessLogOption struct {
+	Format     string   `yaml:"format" default:"combined"` //common, combined, json, or a text/template of AccessLogEntry
+	File       string   `yaml:"file" default:"access.log"` //file under logDir rotated by maxSize/maxAge/maxBackups, stdout to print
+	SampleRate float64  `yaml:"sampleRate" default:"1"`    //(0,1] ratio of requests to log, responses with status >= 400 are always logged
+	Exclude    []string `yaml:"exclude"`                   //paths not to log, e.g. /healthz or /static/*
+}
+
+// AccessLogEntry is a line of access log
+type AccessLogEntry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Query     string        `json:"query,omitempty"`
+	Route     string        `json:"route,omitempty"` // pattern of matched route, e.g. /users/{id}
+	Protocol  string        `json:"protocol"`
+	Status    int           `json:"status"`
+	Bytes     int           `json:"bytes"`
+	Latency   time.Duration `json:"latency"`
+	RemoteIP  string        `json:"remote_ip"`
+	UserAgent string        `json:"user_agent,omitempty"`
+	Referer   string        `json:"referer,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// RoutePattern returns the pattern of matched route, e.g. /users/{id}
+func (c *Context) RoutePattern() string {
+	if p, ok := c.ctx.UserValue(router.MatchedRoutePathParam).(string); ok {
+		return p
+	}
+	return ""
+}
+
+// NewAccessLogMiddleware returns a global middleware which writes a line for every request,
+// it is configured by `logger.accessLog`:
+//
+//	logger:
+//	  logDir: log
+//	  accessLog:
+//	    format: combined # common, combined, json or a template like {{.Method}} {{.Route}} {{.Status}} {{.Latency}}
+//	    file: access.log
+//	    sampleRate: 0.1
+//	    exclude: [/healthz, /static/*]
+func NewAccessLogMiddleware() IMiddlewareGlobal {
+	return &AccessLogMiddleware{
+		MiddlewareGlobal: NewMiddlewareGlobal("AccessLog"),
+	}
+}
+
+type AccessLogMiddleware struct {
+	*MiddlewareGlobal
+	Writer io.Writer // overrides the file of config
+	option AccessLogOption
+	format func(w *bytes.Buffer, e *AccessLogEntry) error
+}
+
+func (a *AccessLogMiddleware) DoInitOnce() {
+	a.option = AccessLogOption{Format: "combined", File: "access.log", SampleRate: 1}
+	logOption := LoggerOption{LogDir: "log"}
+	if s, ok := a.provider.(*Server); ok && s.option != nil {
+		logOption = s.option.Logger
+		a.option = s.option.Logger.AccessLog
+	}
+	if a.Writer == nil {
+		a.Writer = accessLogWriter(logOption, a.option.File)
+	}
+	format, err := accessLogFormat(a.option.Format)
+	if err != nil {
+		panic(fmt.Sprintf("logger.accessLog.format: %v", err))
+	}
+	a.format = format
+}
+
+// Priority makes access log run after RequestID and measure the other middlewares
+func (a *AccessLogMiddleware) Priority() int {
+	return -800
+}
+
+func (a *AccessLogMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	if ctx.Ignored {
+		return ctx.Next
+	}
+	return func(c *Context) {
+		if a.excluded(string(c.ctx.Path())) {
+			ctx.Next(c)
+			return
+		}
+		start := time.Now()
+		ctx.Next(c)
+		status := c.ctx.Response.StatusCode()
+		if status < 400 && a.option.SampleRate > 0 && a.option.SampleRate < 1 && rand.Float64() >= a.option.SampleRate {
+			return
+		}
+		size := len(c.ctx.Response.Body())
+		if size == 0 {
+			size = max(c.ctx.Response.Header.ContentLength(), 0)
+		}
+		a.write(&AccessLogEntry{
+			Time:      start,
+			Method:    string(c.ctx.Method()),
+			Path:      string(c.ctx.Path()),
+			Query:     string(c.ctx.QueryArgs().QueryString()),
+			Route:     c.RoutePattern(),
+			Protocol:  string(c.ctx.Request.Header.Protocol()),
+			Status:    status,
+			Bytes:     size,
+			Latency:   time.Since(start),
+			RemoteIP:  c.RemoteIP(),
+			UserAgent: string(c.ctx.UserAgent()),
+			Referer:   string(c.ctx.Referer()),
+			RequestID: c.RequestID(),
+		})
+	}
+}
+
+func (a *AccessLogMiddleware) write(e *AccessLogEntry) {
+	buf := &bytes.Buffer{}
+	if err := a.format(buf, e); err != nil {
+		return
+	}
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	_, _ = a.Writer.Write(buf.Bytes())
+}
+
+// excluded reports whether p matches one of Exclude, a pattern ending with * matches the prefix
+func (a *AccessLogMiddleware) excluded(p string) bool {
+	for _, pattern := range a.option.Exclude {
+		if pattern == p {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(p, prefix) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// accessLogWriter returns the rotated file under logDir, or stdout
+func accessLogWriter(option LoggerOption, file string) io.Writer {
+	if file == "stdout" {
+		return os.Stdout
+	}
+	if file == "" {
+		file = "access.log"
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(option.LogDir, file)
+	}
+	return &lumberjack.Logger{
+		Filename:   file,
+		Compress:   option.Compress,
+		MaxSize:    option.MaxSize,
+		MaxAge:     option.MaxAge,
+		MaxBackups: option.MaxBackups,
+		LocalTime:  option.LocalTime,
+	}
+}
+
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// accessLogFormat returns the formatter of format
+func accessLogFormat(format string) (func(w *bytes.Buffer, e *AccessLogEntry) error, error) {
+	switch strings.ToLower(format) {
+	case "", "combined":
+		return func(w *bytes.Buffer, e *AccessLogEntry) error {
+			writeCommonLog(w, e)
+			_, _ = fmt.Fprintf(w, " %s %s", strconv.Quote(e.Referer), strconv.Quote(e.UserAgent))
+			return nil
+		}, nil
+	case "common":
+		return func(w *bytes.Buffer, e *AccessLogEntry) error {
+			writeCommonLog(w, e)
+			return nil
+		}, nil
+	case "json":
+		return func(w *bytes.Buffer, e *AccessLogEntry) error {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			w.Write(data)
+			return nil
+		}, nil
+	}
+	if !strings.Contains(format, "{{") {
+		return nil, fmt.Errorf("unknown format %q, should be common, combined, json or a template", format)
+	}
+	tmpl, err := template.New("accessLog").Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	return func(w *bytes.Buffer, e *AccessLogEntry) error {
+		return tmpl.Execute(w, e)
+	}, nil
+}
+
+// writeCommonLog writes e in Common Log Format: host ident authuser [date] "request" status bytes
+func writeCommonLog(w *bytes.Buffer, e *AccessLogEntry) {
+	uri := e.Path
+	if e.Query != "" {
+		uri += "?" + e.Query
+	}
+	size := "-"
+	if e.Bytes > 0 {
+		size = strconv.Itoa(e.Bytes)
+	}
+	_, _ = fmt.Fprintf(w, "%s - - [%s] \"%s %s %s\" %d %s",
+		e.RemoteIP, e.Time.Format(clfTime), e.Method, uri, e.Protocol, e.Status, size)
+}
@@ -0,0 +1,55 @@
+package fw
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  AccessLogOption
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{name: "combined", uri: "/users/1?a=1", want: `0.0.0.0 - - [`},
+		{name: "combined request", uri: "/users/1?a=1", want: `"GET /users/1?a=1 HTTP/1.1" 200 20 "" "fw-test"`},
+		{name: "common", option: AccessLogOption{Format: "common"}, uri: "/users/1", want: `"GET /users/1 HTTP/1.1" 200 20` + "\n"},
+		{name: "json", option: AccessLogOption{Format: "json"}, uri: "/users/1", want: `"route":"/users/{id}","protocol":"HTTP/1.1","status":200,"bytes":20`},
+		{name: "template", option: AccessLogOption{Format: "{{.Method}} {{.Route}} {{.Status}} {{.RequestID}}"}, uri: "/users/1", want: "GET /users/{id} 200 abc\n"},
+		{name: "exclude", option: AccessLogOption{Exclude: []string{"/users/*"}}, uri: "/users/1", want: ""},
+		{name: "bad format", option: AccessLogOption{Format: "apache"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			s.option.Logger.AccessLog = tt.option
+			buf := &bytes.Buffer{}
+			m := NewAccessLogMiddleware()
+			m.(*AccessLogMiddleware).Writer = buf
+			if tt.wantErr {
+				defer func() {
+					if recover() == nil {
+						t.Error("want panic for bad format")
+					}
+				}()
+			}
+			s.Use(m)
+			Handle(s, "GET", "/users/{id}", func(c *Context, req *struct{}) (*testUser, error) {
+				return &testUser{}, nil
+			})
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost" + tt.uri)
+			ctx.Request.Header.SetUserAgent("fw-test")
+			ctx.Request.Header.Set(HeaderXRequestID, "abc")
+			s.Handler()(ctx)
+			if got := buf.String(); tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("access log = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package fw
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/linxlib/astp"
+	"github.com/linxlib/fw/internal"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// embeddedAST is the astp metadata registered by SetEmbeddedAST
+var embeddedAST []byte
+
+// SetEmbeddedAST registers astp metadata embedded in the binary (go:embed).
+// it will be used by New when AstFile is not found, so that a single binary is self-contained.
+// `fw gen --embed` generates a file which calls this in init()
+func SetEmbeddedAST(data []byte) {
+	embeddedAST = data
+}
+
+// NewWithAST creates a server with astp metadata in data instead of reading AstFile
+//
+//	//go:embed gen.gz
+//	var ast []byte
+//
+//	s := fw.NewWithAST(ast)
+func NewWithAST(data []byte, key ...string) *Server {
+	s := newServer(key...)
+	if err := s.readAST(data); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewWithASTFS creates a server with astp metadata read from AstFile in fsys (e.g. embed.FS)
+func NewWithASTFS(fsys fs.FS, key ...string) *Server {
+	s := newServer(key...)
+	data, err := fs.ReadFile(fsys, s.option.AstFile)
+	if err != nil {
+		panic(err)
+	}
+	if err = s.readAST(data); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// loadAST loads astp metadata for server.
+// in Dev mode AstFile will be regenerated when it does not exist or the source files changed,
+// otherwise AstFile will be used if exists, or the embedded metadata will be used.
+func (s *Server) loadAST() {
+	if s.option.Dev {
+		// regenerate metadata when source files changed
+		fingerprint, err := astFingerprint(".")
+		if err != nil {
+			s.logger.Warnf("failed to fingerprint source files: %s", err)
+		}
+		sumFile := s.option.AstFile + astSumExt
+		if !internal.FileIsExist(s.option.AstFile) || fingerprint == "" ||
+			string(bytes.TrimSpace(internal.ReadFile(sumFile))) != fingerprint {
+			if internal.FileIsExist(s.option.AstFile) {
+				s.logger.Infof("source files changed, regenerating %s", s.option.AstFile)
+			}
+			parser := astp.Parser{}
+			parser.Parse()
+			if err = parser.Write(s.option.AstFile); err != nil {
+				s.logger.Errorf("failed to write %s: %s", s.option.AstFile, err)
+			} else if fingerprint != "" {
+				internal.WriteFile(sumFile, []byte(fingerprint), true)
+			}
+		}
+	} else if !internal.FileIsExist(s.option.AstFile) {
+		if len(embeddedAST) == 0 {
+			// typed handlers registered by Handle still work without metadata,
+			// RegisterRoute will panic instead
+			s.logger.Warnf("%s not found, controllers can not be registered", s.option.AstFile)
+			return
+		}
+		if err := s.readAST(embeddedAST); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	s.parser.Read(s.option.AstFile)
+	s.astLoaded = true
+}
+
+// readAST reads astp metadata from data, which can be json or gzipped json
+func (s *Server) readAST(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("astp metadata is empty")
+	}
+	// astp reads metadata from file only, and the format depends on the file extension
+	f, err := os.CreateTemp("", "fw-ast-*"+astExt(data))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	s.parser.Read(f.Name())
+	s.astLoaded = true
+	return nil
+}
+
+// astExt returns the file extension of astp metadata in data, gzip is detected by its magic bytes
+func astExt(data []byte) string {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return ".gz"
+	}
+	return ".json"
+}
+
+// astSumExt is the extension of the file which stores the fingerprint of source files next to AstFile,
+// it is written by `fw gen` as well, keep astFingerprint the same as the one in cmd/commands/gen.go
+const astSumExt = ".sum"
+
+// astFingerprint returns a sha256 hash of all go files (except tests) under dir,
+// hidden directories, vendor and testdata will be skipped.
+func astFingerprint(dir string) (string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		rel, _ := filepath.Rel(dir, file)
+		h.Write([]byte(filepath.ToSlash(rel)))
+		h.Write([]byte{0})
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
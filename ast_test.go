@@ -0,0 +1,125 @@
+package fw
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/linxlib/astp"
+	types2 "github.com/linxlib/astp/types"
+	"github.com/linxlib/fw/inject"
+)
+
+func Test_astFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fingerprint := func() string {
+		t.Helper()
+		fp, err := astFingerprint(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+	write("main.go", "package main")
+	write("controllers/hello.go", "package controllers")
+	fp1 := fingerprint()
+	// `fw gen` writes the fingerprint as well, the same value is expected by its test in cmd/commands
+	if want := "522f4734ba2fc8e5c6a2368e8aa8ca64045067ce2759945a05698fc68a0916bf"; fp1 != want {
+		t.Errorf("fingerprint = %s, want %s", fp1, want)
+	}
+
+	write("main_test.go", "package main")
+	write(".git/hooks.go", "package hooks")
+	write("vendor/a/a.go", "package a")
+	write("config/config.yaml", "port: 2024")
+	if fp2 := fingerprint(); fp2 != fp1 {
+		t.Errorf("fingerprint changed by ignored files")
+	}
+
+	write("controllers/hello.go", "package controllers\n\n// @GET /hello")
+	if fp3 := fingerprint(); fp3 == fp1 {
+		t.Errorf("fingerprint not changed after editing source file")
+	}
+}
+
+func Test_astExt(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write([]byte("{}"))
+	_ = w.Close()
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "json", data: []byte(`{}`), want: ".json"},
+		{name: "gzip", data: gz.Bytes(), want: ".gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := astExt(tt.data); got != tt.want {
+				t.Errorf("astExt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_loadAST_embedded(t *testing.T) {
+	// fw gen --embed writes json by default while AstFile defaults to gen.gz
+	SetEmbeddedAST([]byte(`{}`))
+	defer SetEmbeddedAST(nil)
+	s := &Server{
+		Injector: inject.New(),
+		option:   &ServerOption{AstFile: "gen.gz"},
+		parser:   &astp.Parser{Project: &types2.Project{}},
+	}
+	s.loadAST()
+	if !s.astLoaded {
+		t.Error("embedded json metadata should be loaded")
+	}
+}
+
+type testBaseController struct{}
+
+func (b *testBaseController) Paginate() {}
+
+type testStaleController struct {
+	*testBaseController
+}
+
+func (c *testStaleController) Init(provider IProvider) {}
+func (c *testStaleController) InitConfig(ConfigMapper) {}
+func (c *testStaleController) List()                   {}
+func (c *testStaleController) Create()                 {}
+
+func Test_staleMethods(t *testing.T) {
+	tests := []struct {
+		name  string
+		names map[string]bool
+		want  []string
+	}{
+		{name: "up to date", names: map[string]bool{"List": true, "Create": true}, want: []string{}},
+		{name: "stale", names: map[string]bool{"List": true}, want: []string{"Create"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := staleMethods(reflect.TypeOf(&testStaleController{}), tt.names)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staleMethods() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
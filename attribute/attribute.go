@@ -31,9 +31,11 @@ var innerAttributeTypes = map[string]AttributeType{
 	"OPTIONS": TypeHttpMethod,
 	"TRACE":   TypeHttpMethod,
 	"CONNECT": TypeHttpMethod,
+	"PATCH":   TypeHttpMethod,
 	"ANY":     TypeHttpMethod,
 	"WS":      TypeHttpMethod,
 	"IGNORE":  TypeOther,
+	"STATUS":  TypeOther,
 
 	"ROUTE":      TypeMiddleware,
 	"CONTROLLER": TypeTagger,
@@ -60,6 +62,18 @@ func RegAttributeType(name string, value AttributeType) {
 	}
 }
 
+// GetAttributeType returns the type of a registered attribute (case-insensitive)
+func GetAttributeType(name string) (AttributeType, bool) {
+	t, ok := innerAttributeTypes[strings.ToUpper(name)]
+	return t, ok
+}
+
+// IsHttpMethod reports whether name is a registered http method (case-insensitive)
+func IsHttpMethod(name string) bool {
+	t, ok := GetAttributeType(name)
+	return ok && t == TypeHttpMethod
+}
+
 // ParseDoc 解析注解
 func ParseDoc(doc []string, name string) []*Attribute {
 	if len(doc) == 0 {
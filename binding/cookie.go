@@ -11,17 +11,20 @@ func (cookieBinding) Name() string {
 	return "cookie"
 }
 
-func (cookieBinding) Bind(req *fasthttp.RequestCtx, obj interface{}) error {
+func (b cookieBinding) Bind(req *fasthttp.RequestCtx, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (cookieBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	f := make(map[string][]string)
 	req.Request.Header.VisitAllCookie(func(k, v []byte) {
 		key := conv.String(k)
 		f[key] = append(f[key], conv.String(v))
 	})
-	if err := mapFormByTag(obj, f, "cookie"); err != nil {
-		return err
-	}
-	return validate(obj)
-
+	return mapFormByTag(obj, f, "cookie")
 }
 func (cookieBinding) BindUri(m map[string][]string, obj interface{}) error {
 	if err := mapFormByTag(obj, m, "cookie"); err != nil {
@@ -15,7 +15,14 @@ func (formBinding) Name() string {
 	return "form"
 }
 
-func (formBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+func (b formBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (formBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	f := make(map[string][]string)
 	req.QueryArgs().VisitAll(func(key []byte, value []byte) {
 		k := conv.String(key)
@@ -25,42 +32,44 @@ func (formBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
 		k := conv.String(key)
 		f[k] = append(f[k], conv.String(value))
 	})
-
-	if err := mapForm(obj, f); err != nil {
-		return err
-	}
-	return validate(obj)
+	return mapForm(obj, f)
 }
 
 func (formPostBinding) Name() string {
 	return "form-urlencoded"
 }
 
-func (formPostBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+func (b formPostBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (formPostBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	f := make(map[string][]string)
 	req.Request.PostArgs().VisitAll(func(key, value []byte) {
 		k := conv.String(key)
 		f[k] = append(f[k], conv.String(value))
 	})
-	if err := mapForm(obj, f); err != nil {
-		return err
-	}
-	return validate(obj)
+	return mapForm(obj, f)
 }
 
 func (formMultipartBinding) Name() string {
 	return "multipart/form-data"
 }
 
-func (formMultipartBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
-	mform, err := req.Request.MultipartForm()
-	if err != nil {
+func (b formMultipartBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
+	return validate(obj)
+}
 
-	if err := mappingByPtr(obj, (*multipartRequest)(mform), "multipart"); err != nil {
+func (formMultipartBinding) decode(req *fasthttp.RequestCtx, obj any) error {
+	mform, err := req.Request.MultipartForm()
+	if err != nil {
 		return err
 	}
-
-	return validate(obj)
+	return mappingByPtr(obj, (*multipartRequest)(mform), "multipart")
 }
@@ -34,6 +34,15 @@ func MapFormWithTag(ptr any, form map[string][]string, tag string) error {
 	return mapFormByTag(ptr, form, tag)
 }
 
+// MapForm maps form into ptr by `form` tags and then validates it.
+// it is used for values out of a request, e.g. params of middleware attributes
+func MapForm(ptr any, form map[string][]string) error {
+	if err := mapForm(ptr, form); err != nil {
+		return err
+	}
+	return validate(ptr)
+}
+
 var emptyField = reflect.StructField{}
 
 func mapFormByTag(ptr any, form map[string][]string, tag string) error {
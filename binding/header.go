@@ -13,17 +13,20 @@ func (headerBinding) Name() string {
 	return "header"
 }
 
-func (headerBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+func (b headerBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (headerBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	h := make(map[string][]string)
 	req.Request.Header.VisitAll(func(key, value []byte) {
 		k := conv.String(key)
 		h[k] = append(h[k], conv.String(value))
 	})
-	if err := mapHeader(obj, h); err != nil {
-		return err
-	}
-
-	return validate(obj)
+	return mapHeader(obj, h)
 }
 
 func mapHeader(ptr any, h map[string][]string) error {
@@ -24,16 +24,28 @@ func (jsonBinding) Name() string {
 	return "json"
 }
 
-func (jsonBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+func (b jsonBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
 	if req == nil || req.PostBody() == nil || len(req.PostBody()) <= 0 {
 		return nil
 	}
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
 
+func (jsonBinding) decode(req *fasthttp.RequestCtx, obj any) error {
+	if req == nil || len(req.PostBody()) <= 0 {
+		return nil
+	}
 	return decodeJSON(bytes.NewReader(req.PostBody()), obj)
 }
 
 func (jsonBinding) BindBody(body []byte, obj any) error {
-	return decodeJSON(bytes.NewReader(body), obj)
+	if err := decodeJSON(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validate(obj)
 }
 
 func decodeJSON(r io.Reader, obj any) error {
@@ -44,8 +56,5 @@ func decodeJSON(r io.Reader, obj any) error {
 	if EnableDecoderDisallowUnknownFields {
 		decoder.DisallowUnknownFields()
 	}
-	if err := decoder.Decode(obj); err != nil {
-		return err
-	}
-	return validate(obj)
+	return decoder.Decode(obj)
 }
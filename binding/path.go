@@ -11,17 +11,20 @@ func (pathBinding) Name() string {
 	return "path"
 }
 
-func (pathBinding) Bind(req *fasthttp.RequestCtx, obj interface{}) error {
+func (b pathBinding) Bind(req *fasthttp.RequestCtx, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (pathBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	f := make(map[string][]string)
 	req.VisitUserValues(func(key []byte, a any) {
 		k := conv.String(key)
 		f[k] = append(f[k], conv.String(a))
 	})
-	if err := mapFormByTag(obj, f, "path"); err != nil {
-		return err
-	}
-	return validate(obj)
-
+	return mapFormByTag(obj, f, "path")
 }
 func (pathBinding) BindUri(m map[string][]string, obj interface{}) error {
 	if err := mapURI(obj, m); err != nil {
@@ -17,6 +17,10 @@ func (plainBinding) Bind(req *fasthttp.RequestCtx, obj interface{}) error {
 	return decodePlain(req.PostBody(), obj)
 }
 
+func (plainBinding) decode(req *fasthttp.RequestCtx, obj any) error {
+	return decodePlain(req.PostBody(), obj)
+}
+
 func (plainBinding) BindBody(body []byte, obj any) error {
 	return decodePlain(body, obj)
 }
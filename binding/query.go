@@ -11,15 +11,19 @@ func (queryBinding) Name() string {
 	return "query"
 }
 
-func (queryBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+func (b queryBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (queryBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	values := req.URI().QueryArgs()
 	f := make(map[string][]string)
 	values.VisitAll(func(key, value []byte) {
 		f[conv.String(key)] = append(f[conv.String(key)], arrValues(value)...)
 
 	})
-	if err := mapFormByTag(obj, f, "query"); err != nil {
-		return err
-	}
-	return validate(obj)
+	return mapFormByTag(obj, f, "query")
 }
@@ -0,0 +1,122 @@
+package binding
+
+import (
+	"github.com/valyala/fasthttp"
+	"reflect"
+)
+
+// decoder maps the request into obj without validation
+type decoder interface {
+	decode(req *fasthttp.RequestCtx, obj any) error
+}
+
+var (
+	_ decoder = jsonBinding{}
+	_ decoder = xmlBinding{}
+	_ decoder = formBinding{}
+	_ decoder = formPostBinding{}
+	_ decoder = formMultipartBinding{}
+	_ decoder = queryBinding{}
+	_ decoder = pathBinding{}
+	_ decoder = headerBinding{}
+	_ decoder = cookieBinding{}
+	_ decoder = plainBinding{}
+)
+
+// tagBindings lists the bindings which will be used when a struct field has the tag
+var tagBindings = []struct {
+	tag     string
+	binding Binding
+}{
+	{"path", Path},
+	{"query", Query},
+	{"header", Header},
+	{"cookie", Cookie},
+	{"form", Form},
+}
+
+// BindByTags maps the request into obj according to the struct tags of obj.
+// fields tagged with `path`, `query`, `header`, `cookie` or `form` will be mapped from the corresponding source,
+// then the body will be decoded by body if it is not nil.
+// unlike Binding.Bind, obj will be validated only once after all sources are mapped.
+func BindByTags(req *fasthttp.RequestCtx, obj any, body Binding) error {
+	tags := structTags(reflect.TypeOf(obj))
+	for _, tb := range tagBindings {
+		if !tags[tb.tag] {
+			continue
+		}
+		if err := tb.binding.(decoder).decode(req, obj); err != nil {
+			return err
+		}
+	}
+	if body != nil {
+		if d, ok := body.(decoder); ok {
+			if err := d.decode(req, obj); err != nil {
+				return err
+			}
+		} else if err := body.Bind(req, obj); err != nil {
+			return err
+		}
+	}
+	return validate(obj)
+}
+
+// structTags returns the binding tags used in t (and its embedded structs)
+func structTags(t reflect.Type) map[string]bool {
+	tags := make(map[string]bool)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return tags
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous {
+			for tag := range structTags(sf.Type) {
+				tags[tag] = true
+			}
+			continue
+		}
+		for _, tb := range tagBindings {
+			if _, ok := sf.Tag.Lookup(tb.tag); ok {
+				tags[tb.tag] = true
+			}
+		}
+	}
+	return tags
+}
+
+// HasBodyTags reports whether t has fields which should be decoded from the body,
+// which means fields not tagged with `path`, `query`, `header`, `cookie` or `form`
+func HasBodyTags(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if sf.Anonymous {
+			if HasBodyTags(sf.Type) {
+				return true
+			}
+			continue
+		}
+		tagged := false
+		for _, tb := range tagBindings {
+			if _, ok := sf.Tag.Lookup(tb.tag); ok {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return true
+		}
+	}
+	return false
+}
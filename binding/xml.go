@@ -13,17 +13,24 @@ func (xmlBinding) Name() string {
 	return "xml"
 }
 
-func (xmlBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+func (b xmlBinding) Bind(req *fasthttp.RequestCtx, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (xmlBinding) decode(req *fasthttp.RequestCtx, obj any) error {
 	return decodeXML(req.RequestBodyStream(), obj)
 }
 
 func (xmlBinding) BindBody(body []byte, obj any) error {
-	return decodeXML(bytes.NewReader(body), obj)
-}
-func decodeXML(r io.Reader, obj any) error {
-	decoder := xml.NewDecoder(r)
-	if err := decoder.Decode(obj); err != nil {
+	if err := decodeXML(bytes.NewReader(body), obj); err != nil {
 		return err
 	}
 	return validate(obj)
 }
+func decodeXML(r io.Reader, obj any) error {
+	decoder := xml.NewDecoder(r)
+	return decoder.Decode(obj)
+}
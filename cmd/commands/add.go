@@ -1,9 +1,221 @@
 package commands
 
-import "github.com/urfave/cli/v2"
+import (
+	"bufio"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"go/token"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+)
 
+// component is a kind of file which can be added by `fw add`
+type component struct {
+	Dir      string // package folder
+	Suffix   string // suffix of type name
+	Template string
+	Method   string   // method of fw.Server to use the component in main.go
+	Arg      string   // format of argument, %s is the package and %s is the name
+	Before   []string // see mainCall.Before
+	Config   bool     // always has a config key
+}
+
+var components = map[string]component{
+	"controller": {
+		Dir: "controllers", Suffix: "Controller", Template: "Controller.tmpl",
+		Method: "RegisterRoutes", Arg: "new(%s.%sController)",
+	},
+	"middleware": {
+		Dir: "middlewares", Suffix: "Middleware", Template: "Middleware.tmpl",
+		Method: "Use", Arg: "%s.New%sMiddleware()",
+		Before: []string{"RegisterRoutes", "RegisterRoute"},
+		Config: true,
+	},
+	"mapper": {
+		Dir: "mappers", Suffix: "Mapper", Template: "Mapper.tmpl",
+		Method: "UseMapper", Arg: "new(%s.%sMapper)",
+		Before: []string{"UseService", "UseServiceWithConfig", "Use", "RegisterRoutes", "RegisterRoute"},
+		Config: true,
+	},
+	"service": {
+		Dir: "services", Suffix: "Service", Template: "Service.tmpl",
+		Method: "UseService", Arg: "new(%s.%sService)",
+		Before: []string{"Use", "RegisterRoutes", "RegisterRoute"},
+	},
+}
+
+// Add generates a controller, middleware, mapper or service in its package folder,
+// uses it in main.go and adds a config stub into config/config.yaml for its config key.
+//
+//	fw add controller user
+//	fw add middleware auth
+//	fw add --key cache mapper redis
+//	fw add --config service order
+//
+// flags should be put before the type, they are not parsed after it.
 func Add(c *cli.Context) error {
+	if err := checkAddArgs(c.Args().Slice()); err != nil {
+		return err
+	}
+	kind := strings.ToLower(c.Args().Get(0))
+	comp, ok := components[kind]
+	if !ok {
+		return fmt.Errorf("unknown type %q, should be one of controller/middleware/mapper/service", kind)
+	}
+	name := componentName(c.Args().Get(1), comp.Suffix)
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("invalid name %q", c.Args().Get(1))
+	}
+	module, err := readModulePath("go.mod")
+	if err != nil {
+		return err
+	}
+
+	data := templateData{
+		Name:      name,
+		Inject:    c.Bool("inject"),
+		Config:    comp.Config || c.Bool("config"),
+		ConfigKey: c.String("key"),
+	}
+	if data.ConfigKey == "" {
+		data.ConfigKey = lowerFirst(name)
+	}
+	file := projectFile{
+		Path:     path.Join(comp.Dir, snakeCase(name)+".go"),
+		Template: comp.Template,
+		Data:     data,
+	}
+	if err = writeProjectFiles(".", []projectFile{file}, c.Bool("force")); err != nil {
+		return err
+	}
+
+	method := comp.Method
+	if kind == "service" && data.Config {
+		method = "UseServiceWithConfig"
+	}
+	call := mainCall{
+		ImportPath: module + "/" + comp.Dir,
+		Method:     method,
+		Arg:        fmt.Sprintf(comp.Arg, comp.Dir, name),
+		Before:     comp.Before,
+	}
+	if changed, err := wireMain("main.go", call); err != nil {
+		fmt.Printf("failed to update main.go: %v\nplease add `%s(%s)` manually\n", err, call.Method, call.Arg)
+	} else if changed {
+		fmt.Printf("  update main.go: %s(%s)\n", call.Method, call.Arg)
+	}
+
+	if data.Config {
+		added, err := addConfigStub(configFile, data.ConfigKey, name+comp.Suffix)
+		if err != nil {
+			return err
+		}
+		if added {
+			fmt.Printf("  update %s: %s\n", configFile, data.ConfigKey)
+		}
+	}
+	return nil
+}
 
+// checkAddArgs reports flags after the positional args, which are ignored by the cli silently
+func checkAddArgs(args []string) error {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("flag %s should be put before type and name: fw add [flags] controller/middleware/mapper/service <name>", arg)
+		}
+	}
+	if len(args) > 2 {
+		return fmt.Errorf("unexpected args %s, usage: fw add [flags] controller/middleware/mapper/service <name>", strings.Join(args[2:], " "))
+	}
 	return nil
+}
+
+// componentName returns the type name without suffix, e.g. user_profile -> UserProfile, AuthMiddleware -> Auth
+func componentName(name string, suffix string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	name = sb.String()
+	if len(name) > len(suffix) && strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+		name = name[:len(name)-len(suffix)]
+	}
+	return name
+}
+
+// snakeCase converts UserProfile to user_profile
+func snakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// readModulePath returns the module path in go.mod
+func readModulePath(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("%s not found, please run in the root of project", file)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("module not found in %s", file)
+}
 
+// addConfigStub adds an empty map for key into the yaml file, comments in the file will be kept.
+// it returns false when the key already exists
+func addConfigStub(file string, key string, typ string) (bool, error) {
+	doc, err := readYAMLNode(file)
+	if err != nil {
+		return false, err
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return false, fmt.Errorf("%s should be a map", file)
+	}
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			return false, nil
+		}
+	}
+	root.Content = append(root.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key, HeadComment: "config of " + typ},
+		&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: yaml.FlowStyle},
+	)
+	return true, writeYAMLNode(file, doc)
 }
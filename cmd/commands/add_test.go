@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_componentName(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+		want   string
+	}{
+		{"user", "Controller", "User"},
+		{"user_profile", "Controller", "UserProfile"},
+		{"AuthMiddleware", "Middleware", "Auth"},
+		{"redis-mapper", "Mapper", "Redis"},
+		{"Service", "Service", "Service"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := componentName(tt.name, tt.suffix); got != tt.want {
+				t.Errorf("componentName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_snakeCase(t *testing.T) {
+	tests := map[string]string{
+		"User":        "user",
+		"UserProfile": "user_profile",
+		"HTTPClient":  "http_client",
+	}
+	for name, want := range tests {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func Test_wireMain(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	src := `package main
+
+import (
+	"github.com/linxlib/fw"
+	"example.com/app/controllers"
+)
+
+func main() {
+	// create server
+	s := fw.New()
+	s.Use(cors.NewDefaultCorsMiddleware())
+	s.RegisterRoutes(new(controllers.HelloController))
+	s.Start()
+}
+`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	calls := []mainCall{
+		{ImportPath: "example.com/app/controllers", Method: "RegisterRoutes", Arg: "new(controllers.UserController)"},
+		{ImportPath: "example.com/app/middlewares", Method: "Use", Arg: "middlewares.NewAuthMiddleware()", Before: []string{"RegisterRoutes"}},
+		{ImportPath: "example.com/app/mappers", Method: "UseMapper", Arg: "new(mappers.RedisMapper)", Before: []string{"Use", "RegisterRoutes"}},
+	}
+	for _, call := range calls {
+		if changed, err := wireMain(file, call); err != nil || !changed {
+			t.Fatalf("wireMain(%s) = %v, %v", call.Arg, changed, err)
+		}
+	}
+	if changed, err := wireMain(file, calls[0]); err != nil || changed {
+		t.Errorf("wireMain() should skip existing call, got %v, %v", changed, err)
+	}
+	data, _ := os.ReadFile(file)
+	got := string(data)
+	for _, want := range []string{
+		`"example.com/app/middlewares"`,
+		`"example.com/app/mappers"`,
+		"// create server\n\ts := fw.New()\n\ts.UseMapper(new(mappers.RedisMapper))\n\ts.Use(cors.NewDefaultCorsMiddleware())\n\ts.Use(middlewares.NewAuthMiddleware())\n",
+		"s.RegisterRoutes(new(controllers.HelloController), new(controllers.UserController))\n\ts.Start()",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("main.go should contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func Test_checkAddArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: []string{"controller", "user"}},
+		{args: []string{"mapper"}},
+		{args: []string{"mapper", "redis", "--key", "cache"}, wantErr: true},
+		{args: []string{"service", "order", "-config"}, wantErr: true},
+		{args: []string{"service", "order", "extra"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			if err := checkAddArgs(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("checkAddArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
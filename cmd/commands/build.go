@@ -1,11 +1,245 @@
 package commands
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
 	"fmt"
 	"github.com/urfave/cli/v2"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
 )
 
+// versionPkg is the package whose variables are stamped by ldflags
+const versionPkg = "github.com/linxlib/fw"
+
+// buildTarget is a GOOS/GOARCH pair
+type buildTarget struct {
+	OS   string
+	Arch string
+}
+
+func (t buildTarget) String() string {
+	return t.OS + "_" + t.Arch
+}
+
+// Build regenerates metadata, cross-compiles the project for each target with version information stamped,
+// then packages the binary, config and metadata into an archive per target (zip for windows, tar.gz for others).
+//
+//	fw build -> build for current os and arch
+//	fw build linux arm64
+//	fw build --target linux/amd64,windows/amd64 --version v1.0.0
 func Build(c *cli.Context) error {
-	fmt.Println("build called")
+	targets, err := buildTargets(c.Args().Get(0), c.Args().Get(1), c.StringSlice("target"))
+	if err != nil {
+		return err
+	}
+	module, err := readModulePath("go.mod")
+	if err != nil {
+		return err
+	}
+	name := c.String("name")
+	if name == "" {
+		name = defaultAppName(module)
+	}
+	conf := readProjectConfig()
+	if !c.Bool("skip-gen") {
+		if err = generate(conf.AstFile); err != nil {
+			return fmt.Errorf("failed to generate metadata: %w", err)
+		}
+	}
+	version := c.String("version")
+	if version == "" {
+		version = gitOutput("describe", "--tags", "--always", "--dirty")
+	}
+	if version == "" {
+		version = "dev"
+	}
+	ldflags := strings.Join([]string{
+		"-s", "-w",
+		"-X", versionPkg + ".AppVersion=" + version,
+		"-X", versionPkg + ".Commit=" + gitOutput("rev-parse", "--short", "HEAD"),
+		"-X", versionPkg + ".BuildTime=" + time.Now().UTC().Format(time.RFC3339),
+	}, " ")
+
+	output := c.String("output")
+	for _, target := range targets {
+		fmt.Printf("building %s %s for %s/%s...\n", name, version, target.OS, target.Arch)
+		bin := filepath.Join(output, target.String(), name)
+		if target.OS == "windows" {
+			bin += ".exe"
+		}
+		if err = goBuild(target, ldflags, bin); err != nil {
+			return fmt.Errorf("failed to build for %s/%s: %w", target.OS, target.Arch, err)
+		}
+		files := map[string]string{filepath.Base(bin): bin}
+		if err = collectFiles(files, "config"); err != nil {
+			return err
+		}
+		if _, err = os.Stat(conf.AstFile); err == nil {
+			files[filepath.ToSlash(conf.AstFile)] = conf.AstFile
+		}
+		archive := filepath.Join(output, fmt.Sprintf("%s_%s_%s", name, version, target))
+		if target.OS == "windows" {
+			archive += ".zip"
+			err = writeZip(archive, files)
+		} else {
+			archive += ".tar.gz"
+			err = writeTarGz(archive, files)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  create %s\n", filepath.ToSlash(archive))
+	}
 	return nil
 }
+
+// buildTargets returns targets from `os arch` arguments or --target flags (os/arch), current os and arch by default
+func buildTargets(goos string, arch string, flags []string) ([]buildTarget, error) {
+	var targets []buildTarget
+	if goos != "" {
+		if arch == "" {
+			arch = "amd64"
+		}
+		targets = append(targets, buildTarget{OS: goos, Arch: arch})
+	}
+	for _, flag := range flags {
+		for _, item := range strings.Split(flag, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			parts := strings.Split(item, "/")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("invalid target %q, should be os/arch", item)
+			}
+			targets = append(targets, buildTarget{OS: parts[0], Arch: parts[1]})
+		}
+	}
+	if len(targets) == 0 {
+		targets = append(targets, buildTarget{OS: runtime.GOOS, Arch: runtime.GOARCH})
+	}
+	return targets, nil
+}
+
+func goBuild(target buildTarget, ldflags string, bin string) error {
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags", ldflags, "-o", bin, ".")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "GOOS="+target.OS, "GOARCH="+target.Arch, "CGO_ENABLED=0")
+	return cmd.Run()
+}
+
+// gitOutput runs git and returns the trimmed output, empty when failed
+func gitOutput(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// collectFiles adds files in dir into files (name in archive -> path), dir can be absent
+func collectFiles(files map[string]string, dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		files[filepath.ToSlash(p)] = p
+		return nil
+	})
+}
+
+func writeZip(archive string, files map[string]string) error {
+	f, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, name := range sortedNames(files) {
+		file := files[name]
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err = copyFile(w, file); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTarGz(archive string, files map[string]string) error {
+	f, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, name := range sortedNames(files) {
+		file := files[name]
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Clean(name)
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err = copyFile(tw, file); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func copyFile(w io.Writer, file string) error {
+	r, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// sortedNames returns names of files in order, so archives are reproducible
+func sortedNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
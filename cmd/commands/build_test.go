@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func Test_buildTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		goos    string
+		arch    string
+		flags   []string
+		want    []buildTarget
+		wantErr bool
+	}{
+		{name: "default", want: []buildTarget{{runtime.GOOS, runtime.GOARCH}}},
+		{name: "os only", goos: "windows", want: []buildTarget{{"windows", "amd64"}}},
+		{name: "os and arch", goos: "linux", arch: "arm64", want: []buildTarget{{"linux", "arm64"}}},
+		{
+			name:  "flags",
+			flags: []string{"linux/amd64,darwin/arm64", "windows/amd64"},
+			want:  []buildTarget{{"linux", "amd64"}, {"darwin", "arm64"}, {"windows", "amd64"}},
+		},
+		{name: "invalid", flags: []string{"linux"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildTargets(tt.goos, tt.arch, tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,9 +3,296 @@ package commands
 import (
 	"fmt"
 	"github.com/urfave/cli/v2"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"gopkg.in/yaml.v3"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 )
 
+// envPrefix is the prefix of env which overrides config, same as the option of config in fw.New
+const envPrefix = "FW"
+
+// Config reads or writes config/config.yaml with a dotted path of keys.
+//
+//	fw config -> list all effective values of ServerOption, and the other keys in config file
+//	fw config logger.loggerLevel -> print the value
+//	fw config logger.loggerLevel 5 -> write the value, comments and order of keys are kept
 func Config(c *cli.Context) error {
-	fmt.Println("config called")
+	file := c.String("file")
+	switch c.Args().Len() {
+	case 0:
+		return listConfig(file)
+	case 1:
+		return getConfig(file, c.Args().Get(0))
+	default:
+		return setConfig(file, c.Args().Get(0), strings.Join(c.Args().Slice()[1:], " "))
+	}
+}
+
+func getConfig(file string, key string) error {
+	fields, _ := serverOptionFields()
+	for _, field := range fields {
+		if field.Path == key {
+			if value, ok := field.lookupEnv(); ok {
+				fmt.Println(value)
+				return nil
+			}
+			break
+		}
+	}
+	doc, err := readYAMLNode(file)
+	if err == nil {
+		if node := yamlPath(doc.Content[0], key); node != nil {
+			if node.Kind == yaml.ScalarNode {
+				fmt.Println(node.Value)
+				return nil
+			}
+			out, err := yaml.Marshal(node)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		}
+	}
+	for _, field := range fields {
+		if field.Path == key {
+			fmt.Println(field.Default)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s not found", key)
+}
+
+func setConfig(file string, key string, value string) error {
+	doc, err := readYAMLNode(file)
+	if os.IsNotExist(err) {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+	}
+	if err != nil {
+		return err
+	}
+	if err = setYAMLPath(doc.Content[0], key, value); err != nil {
+		return err
+	}
+	if err = writeYAMLNode(file, doc); err != nil {
+		return err
+	}
+	fields, _ := serverOptionFields()
+	for _, field := range fields {
+		if field.Path == key {
+			if _, ok := field.lookupEnv(); ok {
+				fmt.Printf("warning: %s is overridden by env %s\n", key, field.Env)
+			}
+		}
+	}
 	return nil
 }
+
+func listConfig(file string) error {
+	values := make(map[string]string)
+	var keys []string
+	if doc, err := readYAMLNode(file); err == nil {
+		flattenYAML(doc.Content[0], "", func(path string, value string) {
+			values[path] = value
+			keys = append(keys, path)
+		})
+	}
+	fields, err := serverOptionFields()
+	if err != nil {
+		fmt.Printf("warning: defaults of ServerOption are not available: %v\n", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tENV")
+	known := make(map[string]bool)
+	for _, field := range fields {
+		known[field.Path] = true
+		value, source := field.Default, "default"
+		if v, ok := values[field.Path]; ok {
+			value, source = v, file
+		}
+		if v, ok := field.lookupEnv(); ok {
+			value, source = v, "env"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", field.Path, value, source, field.Env)
+	}
+	for _, key := range keys {
+		if !known[key] {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t\n", key, values[key], file)
+		}
+	}
+	return w.Flush()
+}
+
+// yamlPath returns the node of dotted path, numbers are indexes of sequences
+func yamlPath(node *yaml.Node, path string) *yaml.Node {
+	for _, key := range strings.Split(path, ".") {
+		node = yamlChild(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func yamlChild(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// setYAMLPath sets the scalar value of dotted path, missing maps will be created.
+// the style of an existing value (e.g. quoted) is kept
+func setYAMLPath(node *yaml.Node, path string, value string) error {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		child := yamlChild(node, key)
+		if child == nil {
+			if node.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s is not a map", strings.Join(keys[:i], "."))
+			}
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if i == len(keys)-1 {
+				child = &yaml.Node{Kind: yaml.ScalarNode}
+			}
+			// e.g. `redis: {}` added by fw add
+			node.Style &^= yaml.FlowStyle
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		node = child
+	}
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("%s is not a single value", path)
+	}
+	node.Value = value
+	// let encoder resolve the type of new value
+	node.Tag = ""
+	return nil
+}
+
+// flattenYAML calls fn with dotted path and value of each scalar in node
+func flattenYAML(node *yaml.Node, prefix string, fn func(path string, value string)) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			flattenYAML(node.Content[i+1], join(node.Content[i].Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			flattenYAML(item, join(strconv.Itoa(i)), fn)
+		}
+	case yaml.ScalarNode:
+		fn(prefix, node.Value)
+	case yaml.AliasNode:
+		flattenYAML(node.Alias, prefix, fn)
+	}
+}
+
+// optionField is a field of fw.ServerOption
+type optionField struct {
+	Path    string // dotted yaml path, e.g. logger.loggerLevel
+	Env     string // e.g. FW_LOGGER_LOGGERLEVEL
+	Default string
+	names   []string // go field names
+}
+
+// lookupEnv returns the value of env which overrides the field, the same names as github.com/linxlib/config are tried
+func (f optionField) lookupEnv() (string, bool) {
+	for _, env := range []string{strings.Join(append([]string{envPrefix}, f.names...), "_"), f.Env} {
+		if value := os.Getenv(env); value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// serverOptionFields reads fields of ServerOption from the source of fw used by the project
+func serverOptionFields() ([]optionField, error) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", fwImportPath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in current module", fwImportPath)
+	}
+	return parseOptionFields(strings.TrimSpace(string(out)), "ServerOption")
+}
+
+// parseOptionFields returns the fields of struct typ in package dir which have yaml tags
+func parseOptionFields(dir string, typ string) ([]optionField, error) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	structs := make(map[string]*ast.StructType)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					structs[spec.Name.Name] = st
+				}
+			}
+			return true
+		})
+	}
+	if structs[typ] == nil {
+		return nil, fmt.Errorf("%s not found in %s", typ, dir)
+	}
+	var fields []optionField
+	var walk func(st *ast.StructType, path []string, names []string)
+	walk = func(st *ast.StructType, path []string, names []string) {
+		for _, field := range st.Fields.List {
+			if field.Tag == nil || len(field.Names) == 0 {
+				continue
+			}
+			tag, _ := strconv.Unquote(field.Tag.Value)
+			name, _, _ := strings.Cut(reflect.StructTag(tag).Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			p := append(append([]string{}, path...), name)
+			n := append(append([]string{}, names...), field.Names[0].Name)
+			if ident, ok := field.Type.(*ast.Ident); ok && structs[ident.Name] != nil {
+				walk(structs[ident.Name], p, n)
+				continue
+			}
+			fields = append(fields, optionField{
+				Path:    strings.Join(p, "."),
+				Env:     strings.ToUpper(strings.Join(append([]string{envPrefix}, n...), "_")),
+				Default: reflect.StructTag(tag).Get("default"),
+				names:   n,
+			})
+		}
+	}
+	walk(structs[typ], nil, nil)
+	return fields, nil
+}
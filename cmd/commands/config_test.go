@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_setConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	src := `port: 2024
+name: "demo"
+logger:
+    # 0-6, 0: Panic 6: Trace
+    loggerLevel: 5
+redis: {}
+`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sets := [][2]string{
+		{"logger.loggerLevel", "6"},
+		{"name", "app"},
+		{"redis.host", "127.0.0.1"},
+		{"basePath", "/api"},
+	}
+	for _, set := range sets {
+		if err := setConfig(file, set[0], set[1]); err != nil {
+			t.Fatalf("setConfig(%s) error = %v", set[0], err)
+		}
+	}
+	if err := setConfig(file, "logger", "1"); err == nil {
+		t.Errorf("setConfig() should fail for a map")
+	}
+	data, _ := os.ReadFile(file)
+	want := `port: 2024
+name: "app"
+logger:
+    # 0-6, 0: Panic 6: Trace
+    loggerLevel: 6
+redis:
+    host: 127.0.0.1
+basePath: /api
+`
+	if string(data) != want {
+		t.Errorf("config = \n%s\nwant\n%s", data, want)
+	}
+
+	doc := new(yaml.Node)
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		t.Fatal(err)
+	}
+	if node := yamlPath(doc.Content[0], "redis.host"); node == nil || node.Value != "127.0.0.1" {
+		t.Errorf("yamlPath() = %v", node)
+	}
+}
+
+func Test_parseOptionFields(t *testing.T) {
+	// the source of fw is the parent of this module
+	fields, err := parseOptionFields(filepath.Join("..", ".."), "ServerOption")
+	if err != nil {
+		t.Fatalf("parseOptionFields() error = %v", err)
+	}
+	got := make(map[string]optionField)
+	for _, field := range fields {
+		got[field.Path] = field
+	}
+	if f := got["port"]; f.Default != "2024" || f.Env != "FW_PORT" {
+		t.Errorf("port = %+v", f)
+	}
+	if f := got["logger.loggerLevel"]; f.Env != "FW_LOGGER_LOGGERLEVEL" {
+		t.Errorf("logger.loggerLevel = %+v", f)
+	}
+	t.Setenv("FW_LOGGER_LOGGERLEVEL", "6")
+	if v, ok := got["logger.loggerLevel"].lookupEnv(); !ok || v != "6" {
+		t.Errorf("lookupEnv() = %v, %v", v, ok)
+	}
+	for path := range got {
+		if strings.Contains(strings.ToLower(path), "intranet") {
+			t.Errorf("field without yaml tag should be skipped: %s", path)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// devDir stores the binary built by `fw dev`
+const devDir = ".fw"
+
+// Dev watches source, config and template files, then regenerates metadata,
+// rebuilds and restarts the app when something changed.
+// a proxy listens on the configured port and holds requests while the app is restarting,
+// so the browser will not see connection refused.
+// use --key if the app loads its config with a key (fw.New(key)), so that the config and env of it are used.
+func Dev(c *cli.Context) error {
+	key := c.String("key")
+	conf := readProjectConfig(key)
+	listen := c.String("listen")
+	if listen == "" {
+		listen = conf.Listen
+	}
+	port := c.Int("port")
+	if port == 0 {
+		port = conf.Port
+	}
+	appPort := c.Int("app-port")
+	if appPort == 0 {
+		appPort = port + 1
+	}
+	output := c.String("output")
+	if output == "" {
+		output = conf.AstFile
+	}
+
+	proxy, err := newDevProxy(appPort, c.Duration("hold"))
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(listen, strconv.Itoa(port))
+	go func() {
+		if err := http.ListenAndServe(addr, proxy); err != nil {
+			fmt.Printf("dev proxy stopped: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+	fmt.Printf("dev proxy listening on http://%s -> app on 127.0.0.1:%d\n", addr, appPort)
+
+	w := newWatcher(".", c.Duration("interval"))
+	for {
+		app := startApp(key, output, appPort)
+		changed := w.wait()
+		fmt.Printf("%s changed, restarting...\n", changed)
+		app.stop()
+	}
+}
+
+// projectConfig is the part of config/config.yaml used by fw commands
+type projectConfig struct {
+	Listen   string `yaml:"listen"`
+	Port     int    `yaml:"port"`
+	AstFile  string `yaml:"astFile"`
+	BasePath string `yaml:"basePath"`
+}
+
+// readProjectConfig reads config/config.yaml (or the part under key) with the same defaults as fw.ServerOption
+func readProjectConfig(key ...string) projectConfig {
+	conf := projectConfig{}
+	if len(key) > 0 && key[0] != "" {
+		confs := map[string]projectConfig{}
+		_ = readYAML(configFile, &confs)
+		conf = confs[key[0]]
+	} else {
+		_ = readYAML(configFile, &conf)
+	}
+	if conf.Listen == "" || conf.Listen == "0.0.0.0" {
+		conf.Listen = "127.0.0.1"
+	}
+	if conf.Port == 0 {
+		conf.Port = 2024
+	}
+	if conf.AstFile == "" {
+		conf.AstFile = "gen.gz"
+	}
+	if conf.BasePath == "" {
+		conf.BasePath = "/"
+	}
+	return conf
+}
+
+type devApp struct {
+	cmd *exec.Cmd
+}
+
+// startApp generates metadata, builds and starts the app listening on port.
+// errors will be printed and the app will be started after next change
+func startApp(key string, output string, port int) *devApp {
+	app := &devApp{}
+	if err := generate(output); err != nil {
+		fmt.Printf("failed to generate metadata: %v\n", err)
+		return app
+	}
+	bin := filepath.Join(devDir, "app")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Printf("failed to build: %v\n", err)
+		return app
+	}
+	app.cmd = exec.Command(bin)
+	app.cmd.Stdout = os.Stdout
+	app.cmd.Stderr = os.Stderr
+	app.cmd.Env = append(os.Environ(), devEnv(key, output, port)...)
+	if err := app.cmd.Start(); err != nil {
+		fmt.Printf("failed to start: %v\n", err)
+		app.cmd = nil
+	}
+	return app
+}
+
+// devEnv returns env which overrides listen, port and astFile of the app,
+// named as github.com/linxlib/config does for the config key passed to fw.New, e.g. FW_SERVER_PORT for key server
+func devEnv(key string, output string, port int) []string {
+	prefix := envPrefix + "_"
+	if key != "" {
+		prefix += strings.ToUpper(key) + "_"
+	}
+	return []string{
+		prefix + "LISTEN=127.0.0.1",
+		prefix + "PORT=" + strconv.Itoa(port),
+		prefix + "ASTFILE=" + output,
+	}
+}
+
+func (a *devApp) stop() {
+	if a.cmd == nil || a.cmd.Process == nil {
+		return
+	}
+	_ = a.cmd.Process.Kill()
+	_ = a.cmd.Wait()
+}
+
+// newDevProxy returns a reverse proxy to the app on port.
+// connecting will be retried until hold timeout, so requests are held while the app is restarting
+func newDevProxy(port int, hold time.Duration) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse("http://127.0.0.1:" + strconv.Itoa(port))
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	dialer := &net.Dialer{Timeout: time.Second}
+	proxy.Transport = &http.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			deadline := time.Now().Add(hold)
+			for {
+				conn, err := dialer.DialContext(ctx, network, addr)
+				if err == nil || time.Now().After(deadline) || ctx.Err() != nil {
+					return conn, err
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		},
+	}
+	return proxy, nil
+}
+
+// watcher polls modification time of watched files
+type watcher struct {
+	dir      string
+	interval time.Duration
+	files    map[string]time.Time
+}
+
+func newWatcher(dir string, interval time.Duration) *watcher {
+	w := &watcher{dir: dir, interval: interval}
+	w.files = w.scan()
+	return w
+}
+
+// watchedExts are extensions of template files which will trigger restarting
+var watchedExts = map[string]bool{".go": true, ".html": true, ".tmpl": true, ".tpl": true, ".gohtml": true}
+
+// isWatched reports whether path should trigger restarting:
+// go files, config/*.yaml and template files
+func isWatched(path string) bool {
+	ext := filepath.Ext(path)
+	if ext == ".yaml" || ext == ".yml" {
+		return filepath.Base(filepath.Dir(path)) == "config"
+	}
+	return watchedExts[ext] && !strings.HasSuffix(path, "_test.go")
+}
+
+func (w *watcher) scan() map[string]time.Time {
+	files := make(map[string]time.Time)
+	_ = filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != w.dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isWatched(path) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[path] = info.ModTime()
+		}
+		return nil
+	})
+	return files
+}
+
+// wait blocks until some files changed and stay unchanged for an interval, returns one of changed files
+func (w *watcher) wait() string {
+	for {
+		time.Sleep(w.interval)
+		files := w.scan()
+		changed := diffFiles(w.files, files)
+		if changed == "" {
+			continue
+		}
+		// wait for editors to finish writing
+		for {
+			time.Sleep(w.interval)
+			next := w.scan()
+			if diffFiles(files, next) == "" {
+				break
+			}
+			files = next
+		}
+		w.files = files
+		return changed
+	}
+}
+
+// diffFiles returns a file which is added, removed or modified
+func diffFiles(old, new map[string]time.Time) string {
+	for path, t := range new {
+		if t1, ok := old[path]; !ok || !t1.Equal(t) {
+			return path
+		}
+	}
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			return path
+		}
+	}
+	return ""
+}
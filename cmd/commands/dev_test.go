@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_isWatched(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", true},
+		{"controllers/user.go", true},
+		{"controllers/user_test.go", false},
+		{"config/config.yaml", true},
+		{"docker-compose.yaml", false},
+		{"templates/index.html", true},
+		{"gen.gz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isWatched(filepath.FromSlash(tt.path)); got != tt.want {
+				t.Errorf("isWatched() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_devEnv(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "FW_LISTEN=127.0.0.1 FW_PORT=2025 FW_ASTFILE=gen.gz"},
+		{"server", "FW_SERVER_LISTEN=127.0.0.1 FW_SERVER_PORT=2025 FW_SERVER_ASTFILE=gen.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := strings.Join(devEnv(tt.key, "gen.gz", 2025), " "); got != tt.want {
+				t.Errorf("devEnv() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// doctorTool is the package in fw which lints astp metadata
+const doctorTool = fwImportPath + "/tools/doctor"
+
+// Doctor reports problems of controller annotations with file:line positions:
+// body params on @GET/@HEAD methods, unknown middlewares, duplicate routes and @Ignore naming an unused middleware.
+// the same checks are done when the server starts in dev mode.
+func Doctor(c *cli.Context) error {
+	conf := readProjectConfig()
+	file := c.String("file")
+	if file == "" {
+		file = conf.AstFile
+	}
+	if c.Bool("gen") {
+		if err := generate(file); err != nil {
+			return err
+		}
+	}
+	middlewares := strings.Join(c.StringSlice("middleware"), ",")
+	if middlewares == "" {
+		fmt.Println("unknown middlewares are not checked, use --middleware to list middlewares used by project")
+	}
+	cmd := exec.Command("go", "run", doctorTool, "-f", file, "-dir", ".", "-base", conf.BasePath, "-middlewares", middlewares)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
@@ -1,16 +1,151 @@
 package commands
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/linxlib/fw/cmd/utils"
 	"github.com/urfave/cli/v2"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
 )
 
+// embedFile is the go file generated by `fw gen --embed`
+const embedFile = "fw_ast.go"
+
+var embedTmpl = template.Must(template.New("embed").Parse(`// Code generated by fw gen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	_ "embed"
+	"github.com/linxlib/fw"
+)
+
+//go:embed {{.File}}
+var fwAST []byte
+
+func init() {
+	fw.SetEmbeddedAST(fwAST)
+}
+`))
+
 func Generate(c *cli.Context) error {
-	fmt.Println("gen called")
-	err := utils.RunCmd("go", "run", "github.com/linxlib/astp/astpg", "-o", "gen.json")
+	output := c.String("output")
+	if err := generate(output); err != nil {
+		return err
+	}
+	if c.Bool("embed") {
+		return writeEmbedFile(output)
+	}
+	return nil
+}
+
+// astSumExt is the extension of the file which stores the fingerprint of source files next to output,
+// fw reads it in Dev mode and only regenerates metadata when the source files changed
+const astSumExt = ".sum"
+
+// generate runs astp generator to write project metadata into output, and the fingerprint of source files
+func generate(output string) error {
+	if err := utils.RunCmd("go", "run", "github.com/linxlib/astp/astpg", "-o", output); err != nil {
+		return err
+	}
+	fingerprint, err := astFingerprint(".")
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(output+astSumExt, []byte(fingerprint), 0644)
+}
+
+// astFingerprint returns a sha256 hash of all go files (except tests) under dir,
+// hidden directories, vendor and testdata will be skipped.
+// it should be the same as astFingerprint of fw, otherwise fw regenerates metadata on every start in Dev mode
+func astFingerprint(dir string) (string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		rel, _ := filepath.Rel(dir, file)
+		h.Write([]byte(filepath.ToSlash(rel)))
+		h.Write([]byte{0})
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeEmbedFile writes a go file which embeds the metadata file into binary
+func writeEmbedFile(output string) error {
+	f, err := os.Create(embedFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = embedTmpl.Execute(f, map[string]string{
+		"Package": packageName("."),
+		"File":    filepath.ToSlash(output),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s generated, %s will be embedded into binary\n", embedFile, output)
 	return nil
 }
+
+// packageName returns the package name of go files in dir, "main" by default
+func packageName(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == embedFile {
+			continue
+		}
+		if name := readPackageName(file); name != "" {
+			return name
+		}
+	}
+	return "main"
+}
+
+func readPackageName(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if name, ok := strings.CutPrefix(line, "package "); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_astFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":              "package main",
+		"controllers/hello.go": "package controllers",
+		"main_test.go":         "package main",
+		".git/hooks.go":        "package hooks",
+		"vendor/a/a.go":        "package a",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := astFingerprint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same value is expected by Test_astFingerprint of fw, which reads the fingerprint in Dev mode
+	if want := "522f4734ba2fc8e5c6a2368e8aa8ca64045067ce2759945a05698fc68a0916bf"; got != want {
+		t.Errorf("astFingerprint() = %s, want %s", got, want)
+	}
+}
@@ -3,9 +3,128 @@ package commands
 import (
 	"fmt"
 	"github.com/urfave/cli/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
+// templateData is the data used to render templates
+type templateData struct {
+	PkgName   string // module path of project
+	Name      string
+	Title     string
+	Listen    string
+	Port      int
+	System    string
+	Arch      string
+	Image     string
+	Desc      string
+	User      string
+	Exec      string
+	WorkDir   string
+	Inject    bool
+	Config    bool
+	ConfigKey string
+}
+
+// goVersion is the go version of generated projects (go.mod and Dockerfile),
+// it should be the one required by go.mod of fw, the official image does not download toolchains
+const goVersion = "1.24"
+
+// GoVersion returns the go version of generated projects
+func (templateData) GoVersion() string {
+	return goVersion
+}
+
+const goModTmpl = `module {{.PkgName}}
+
+go {{.GoVersion}}
+`
+
+// Init creates a fw project with go.mod, main.go, config, a sample controller and build scripts.
+// Dockerfile, docker-compose.yaml and systemd unit are optional.
+// questions will be asked in terminal if module is not given.
 func Init(c *cli.Context) error {
-	fmt.Println("init called")
+	module := c.Args().First()
+	data := templateData{
+		Title:  c.String("title"),
+		Listen: c.String("listen"),
+		Port:   c.Int("port"),
+		Name:   c.String("name"),
+	}
+	docker := c.Bool("docker")
+	systemd := c.Bool("systemd")
+	if module == "" {
+		p := newPrompter()
+		module = p.String("module path", "")
+		if module == "" {
+			return fmt.Errorf("module path is required")
+		}
+		data.Name = p.String("app name", defaultAppName(module))
+		data.Title = p.String("title", data.Name+" api")
+		data.Port = p.Int("port", data.Port)
+		docker = p.Bool("create Dockerfile and docker-compose.yaml?", docker)
+		systemd = p.Bool("create systemd unit?", systemd)
+	}
+	data.PkgName = module
+	if data.Name == "" {
+		data.Name = defaultAppName(module)
+	}
+	if data.Title == "" {
+		data.Title = data.Name + " api"
+	}
+	if docker {
+		// the app should be accessible from outside of container
+		data.Listen = "0.0.0.0"
+	}
+	data.Image = data.Name + ":latest"
+	data.Desc = data.Title
+	data.User = "root"
+	data.WorkDir = path.Join("/opt", data.Name)
+	data.Exec = path.Join(data.WorkDir, data.Name)
+
+	linux, windows := data, data
+	linux.System, linux.Arch = "linux", "amd64"
+	windows.System, windows.Arch = "windows", "amd64"
+	hello := data
+	hello.Name = "Hello"
+
+	files := []projectFile{
+		{Path: "go.mod", Template: goModTmpl, Inline: true, Data: data},
+		{Path: "main.go", Template: "Main.tmpl", Data: data},
+		{Path: configFile, Template: "Config.tmpl", Data: data},
+		{Path: "controllers/hello.go", Template: "Controller.tmpl", Data: hello},
+		{Path: "build.sh", Template: "BuildSh.tmpl", Data: linux},
+		{Path: "build.bat", Template: "BuildBat.tmpl", Data: windows},
+	}
+	if docker {
+		files = append(files,
+			projectFile{Path: "Dockerfile", Template: "Dockerfile.tmpl", Data: data},
+			projectFile{Path: "docker-compose.yaml", Template: "DockerCompose.tmpl", Data: data},
+		)
+	}
+	if systemd {
+		files = append(files, projectFile{Path: data.Name + ".service", Template: "Systemd.tmpl", Data: data})
+	}
+
+	dir := c.String("dir")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	fmt.Printf("creating %s in %s\n", module, dir)
+	if err := writeProjectFiles(dir, files, c.Bool("force")); err != nil {
+		return err
+	}
+	fmt.Printf("done, run `go mod tidy && fw gen && go run .` in %s\n", filepath.ToSlash(dir))
 	return nil
 }
+
+// defaultAppName returns the last element of module path
+func defaultAppName(module string) string {
+	name := path.Base(strings.TrimSuffix(module, "/"))
+	if name == "." || name == "/" {
+		return "app"
+	}
+	return name
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/linxlib/fw/cmd/templates"
+	"go/format"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// configFile is the config file of fw project
+const configFile = "config/config.yaml"
+
+// readYAML reads yaml file into v
+func readYAML(file string, v any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// readYAMLNode reads yaml file as node, so comments and order of keys can be kept when writing back
+func readYAMLNode(file string) (*yaml.Node, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	doc := new(yaml.Node)
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		// empty file
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	return doc, nil
+}
+
+// writeYAMLNode writes node into yaml file with the same indent as config template
+func writeYAMLNode(file string, doc *yaml.Node) error {
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// projectFile is a file rendered from template
+type projectFile struct {
+	Path     string // destination path
+	Template string // template name in templates.FS, or template content when Inline is true
+	Inline   bool
+	Data     any
+}
+
+// writeProjectFiles renders files into dir.
+// existing files will not be overwritten unless force is true, and nothing will be written in that case
+func writeProjectFiles(dir string, files []projectFile, force bool) error {
+	if !force {
+		exists := make([]string, 0)
+		for _, file := range files {
+			if _, err := os.Stat(filepath.Join(dir, file.Path)); err == nil {
+				exists = append(exists, file.Path)
+			}
+		}
+		if len(exists) > 0 {
+			return fmt.Errorf("%s already exists, use --force to overwrite", strings.Join(exists, ", "))
+		}
+	}
+	for _, file := range files {
+		var tmpl *template.Template
+		var err error
+		if file.Inline {
+			tmpl, err = template.New(file.Path).Parse(file.Template)
+		} else {
+			tmpl, err = template.ParseFS(templates.FS, file.Template)
+		}
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, file.Path)
+		if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		buf := new(bytes.Buffer)
+		if err = tmpl.Execute(buf, file.Data); err != nil {
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+		data := buf.Bytes()
+		if filepath.Ext(dst) == ".go" {
+			if formatted, err := format.Source(data); err == nil {
+				data = formatted
+			}
+		}
+		perm := os.FileMode(0644)
+		if strings.HasSuffix(dst, ".sh") {
+			perm = 0755
+		}
+		if err = os.WriteFile(dst, data, perm); err != nil {
+			return err
+		}
+		fmt.Printf("  create %s\n", filepath.ToSlash(dst))
+	}
+	return nil
+}
+
+// prompter asks questions in terminal
+type prompter struct {
+	r *bufio.Reader
+}
+
+func newPrompter() *prompter {
+	return &prompter{r: bufio.NewReader(os.Stdin)}
+}
+
+// String asks a question and returns the answer, def will be returned when answer is empty
+func (p *prompter) String(question string, def string) string {
+	if def != "" {
+		fmt.Printf("%s (%s): ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	line, _ := p.r.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def
+	}
+	return line
+}
+
+// Bool asks a yes/no question
+func (p *prompter) Bool(question string, def bool) bool {
+	d := "y/N"
+	if def {
+		d = "Y/n"
+	}
+	switch strings.ToLower(p.String(question, d)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
+
+// Int asks a question which answer is a number
+func (p *prompter) Int(question string, def int) int {
+	v, err := strconv.Atoi(p.String(question, strconv.Itoa(def)))
+	if err != nil {
+		return def
+	}
+	return v
+}
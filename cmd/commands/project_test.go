@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_writeProjectFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []projectFile{
+		{Path: "go.mod", Template: goModTmpl, Inline: true, Data: templateData{PkgName: "example.com/app"}},
+		{Path: "build.sh", Template: "BuildSh.tmpl", Data: templateData{Name: "app", System: "linux", Arch: "amd64"}},
+	}
+	if err := writeProjectFiles(dir, files, false); err != nil {
+		t.Fatalf("writeProjectFiles() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil || string(data) != "module example.com/app\n\ngo "+goVersion+"\n" {
+		t.Errorf("go.mod = %q, err = %v", data, err)
+	}
+	if err := writeProjectFiles(dir, files, false); err == nil {
+		t.Errorf("writeProjectFiles() should refuse to overwrite existing files")
+	}
+	if err := writeProjectFiles(dir, files, true); err != nil {
+		t.Errorf("writeProjectFiles() with force error = %v", err)
+	}
+}
+
+// generated projects depend on fw, so they should use the go version required by it
+func Test_goVersion(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "go.mod"))
+	if err != nil {
+		t.Skipf("go.mod of fw not found: %v", err)
+	}
+	m := regexp.MustCompile(`(?m)^go (\d+\.\d+)`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("go directive not found in go.mod of fw")
+	}
+	if string(m[1]) != goVersion {
+		t.Errorf("goVersion = %s, fw requires go %s", goVersion, m[1])
+	}
+
+	dir := t.TempDir()
+	files := []projectFile{{Path: "Dockerfile", Template: "Dockerfile.tmpl", Data: templateData{Name: "app", Port: 2024}}}
+	if err := writeProjectFiles(dir, files, false); err != nil {
+		t.Fatalf("writeProjectFiles() error = %v", err)
+	}
+	dockerfile, _ := os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if !strings.HasPrefix(string(dockerfile), "FROM golang:"+goVersion+"-alpine ") {
+		t.Errorf("Dockerfile should build with go %s:\n%s", goVersion, dockerfile)
+	}
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"github.com/urfave/cli/v2"
+	"os"
+	"os/exec"
+)
+
+// routesTool is the package in fw which prints routes from astp metadata
+const routesTool = fwImportPath + "/tools/routes"
+
+// Routes prints all routes declared in astp metadata without starting the server.
+// the metadata is read by the fw version used by the project, so the project should depend on fw
+func Routes(c *cli.Context) error {
+	conf := readProjectConfig()
+	file := c.String("file")
+	if file == "" {
+		file = conf.AstFile
+	}
+	if c.Bool("gen") {
+		if err := generate(file); err != nil {
+			return err
+		}
+	}
+	cmd := exec.Command("go", "run", routesTool, "-f", file, "-base", conf.BasePath, "-format", c.String("format"))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
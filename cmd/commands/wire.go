@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const fwImportPath = "github.com/linxlib/fw"
+
+// mainCall is a call on the fw server which will be added into func main
+type mainCall struct {
+	ImportPath string   // package of Arg
+	Method     string   // method of fw.Server, e.g. Use, RegisterRoutes
+	Arg        string   // e.g. new(controllers.HelloController)
+	Before     []string // the call will be inserted before the first call of these methods, or before Start
+}
+
+// sourceEdit inserts text at offset
+type sourceEdit struct {
+	offset int
+	text   string
+}
+
+// wireMain adds call into func main of file and imports the package.
+// calls of RegisterRoutes will be merged into the existing one.
+// it returns false when the call already exists.
+func wireMain(file string, call mainCall) (bool, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return false, err
+	}
+	mainFunc := findMainFunc(f)
+	if mainFunc == nil {
+		return false, fmt.Errorf("func main not found in %s", file)
+	}
+	server := findServerVar(f, mainFunc)
+	if server == "" {
+		return false, fmt.Errorf("fw server not found in func main of %s", file)
+	}
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+
+	calls := make(map[string]*ast.CallExpr)
+	var stmts []ast.Stmt
+	for _, stmt := range mainFunc.Body.List {
+		method, expr := serverCall(stmt, server)
+		if expr == nil {
+			continue
+		}
+		for _, arg := range expr.Args {
+			if method == call.Method && string(src[offset(arg.Pos()):offset(arg.End())]) == call.Arg {
+				return false, nil
+			}
+		}
+		if _, ok := calls[method]; !ok {
+			calls[method] = expr
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	var edits []sourceEdit
+	if exist, ok := calls[call.Method]; ok && call.Method == "RegisterRoutes" {
+		text := ", " + call.Arg
+		if len(exist.Args) == 0 {
+			text = call.Arg
+		} else if bytes.HasSuffix(bytes.TrimSpace(src[:offset(exist.Rparen)]), []byte(",")) {
+			// arguments in multiple lines
+			text = call.Arg + ",\n"
+		}
+		edits = append(edits, sourceEdit{offset: offset(exist.Rparen), text: text})
+	} else {
+		stmt := fmt.Sprintf("%s.%s(%s)\n", server, call.Method, call.Arg)
+		at := -1
+		for _, s := range stmts {
+			method, _ := serverCall(s, server)
+			if method == "Start" || containsString(call.Before, method) {
+				at = offset(s.Pos())
+				break
+			}
+		}
+		if at < 0 {
+			at = offset(mainFunc.Body.Rbrace)
+		}
+		edits = append(edits, sourceEdit{offset: at, text: stmt})
+	}
+	if edit, ok := importEdit(f, fset, call.ImportPath); ok {
+		edits = append(edits, edit)
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].offset > edits[j].offset
+	})
+	for _, edit := range edits {
+		src = append(src[:edit.offset], append([]byte(edit.text), src[edit.offset:]...)...)
+	}
+	src, err = format.Source(src)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(file, src, 0644)
+}
+
+func findMainFunc(f *ast.File) *ast.FuncDecl {
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" && fn.Body != nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// findServerVar returns the variable assigned by fw.New... in func main
+func findServerVar(f *ast.File, mainFunc *ast.FuncDecl) string {
+	fwName := importName(f, fwImportPath)
+	if fwName == "" {
+		return ""
+	}
+	for _, stmt := range mainFunc.Body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) == 0 || len(assign.Rhs) == 0 {
+			continue
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == fwName && strings.HasPrefix(sel.Sel.Name, "New") {
+			if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
+				return ident.Name
+			}
+		}
+	}
+	return ""
+}
+
+// serverCall returns the method and call expression when stmt is a call on server
+func serverCall(stmt ast.Stmt, server string) (string, *ast.CallExpr) {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return "", nil
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return "", nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", nil
+	}
+	if x, ok := sel.X.(*ast.Ident); ok && x.Name == server {
+		return sel.Sel.Name, call
+	}
+	return "", nil
+}
+
+// importName returns the name used for the imported path, empty when not imported
+func importName(f *ast.File, path string) string {
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		if p != path {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
+
+// importEdit returns the edit to import path, false when it is imported already
+func importEdit(f *ast.File, fset *token.FileSet, path string) (sourceEdit, bool) {
+	if path == "" || importName(f, path) != "" {
+		return sourceEdit{}, false
+	}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+			return sourceEdit{offset: fset.Position(gen.Rparen).Offset, text: strconv.Quote(path) + "\n"}, true
+		}
+	}
+	return sourceEdit{offset: fset.Position(f.Name.End()).Offset, text: "\n\nimport " + strconv.Quote(path)}, true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/urfave/cli/v2 v2.27.5
 	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
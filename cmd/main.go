@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"github.com/linxlib/fw/cmd/commands"
 	"github.com/urfave/cli/v2"
 	"os"
+	"time"
 )
 
 func main() {
@@ -19,13 +21,108 @@ func main() {
 				Name:    "init",
 				Aliases: []string{"i"},
 				Usage:   "init fw project",
-				Action:  commands.Init,
+				UsageText: `fw init -> create project interactively
+fw init --docker --systemd github.com/you/app -> create project with Dockerfile and systemd unit`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Value: ".",
+						Usage: "directory of project",
+					},
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "name of app, default is the last element of module path",
+					},
+					&cli.StringFlag{
+						Name:  "title",
+						Usage: "title of app",
+					},
+					&cli.StringFlag{
+						Name:  "listen",
+						Value: "127.0.0.1",
+						Usage: "listen address",
+					},
+					&cli.IntFlag{
+						Name:  "port",
+						Value: 2024,
+						Usage: "listen port",
+					},
+					&cli.BoolFlag{
+						Name:  "docker",
+						Usage: "create Dockerfile and docker-compose.yaml",
+					},
+					&cli.BoolFlag{
+						Name:  "systemd",
+						Usage: "create systemd unit",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "overwrite existing files",
+					},
+				},
+				Action: commands.Init,
 			},
 			{
 				Name:    "gen",
 				Aliases: []string{"g"},
 				Usage:   "generate project metadata to gen.json",
-				Action:  commands.Generate,
+				UsageText: `fw gen -> generate project metadata to gen.json
+fw gen -o gen.gz --embed -> generate metadata and a go file which embeds it into binary`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Value:   "gen.json",
+						Usage:   "output file of metadata, should be the same as astFile in config",
+					},
+					&cli.BoolFlag{
+						Name:  "embed",
+						Usage: "generate fw_ast.go to embed metadata into binary via go:embed",
+					},
+				},
+				Action: commands.Generate,
+			},
+			{
+				Name:    "dev",
+				Aliases: []string{"d"},
+				Usage:   "run project with hot reload",
+				UsageText: `fw dev -> watch files, regenerate metadata, rebuild and restart project when changed
+fw dev --port 2024 --app-port 2025 -> proxy on 2024 and run project on 2025
+fw dev --key server -> for projects created by fw.New("server")`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "listen",
+						Usage: "listen address of proxy, default is listen in config",
+					},
+					&cli.IntFlag{
+						Name:  "port",
+						Usage: "listen port of proxy, default is port in config",
+					},
+					&cli.IntFlag{
+						Name:  "app-port",
+						Usage: "port of the project while developing, default is port+1",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "output file of metadata, default is astFile in config",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Value: 500 * time.Millisecond,
+						Usage: "interval of checking file changes",
+					},
+					&cli.StringFlag{
+						Name:  "key",
+						Usage: "config key passed to fw.New by the project, empty if it uses the root of config",
+					},
+					&cli.DurationFlag{
+						Name:  "hold",
+						Value: 10 * time.Second,
+						Usage: "how long requests will be held while restarting",
+					},
+				},
+				Action: commands.Dev,
 			},
 			{
 				Name:        "config",
@@ -33,25 +130,130 @@ func main() {
 				Usage:       "config fw project",
 				Description: "",
 				UsageText: `fw config <key> <value> -> write config to config/config.yaml
-fw config <key> -> read config from config/config.yaml`,
+fw config <key> -> read config from config/config.yaml
+fw config -> list all effective configs with defaults and env overrides
+key is a dotted path, e.g. fw config logger.loggerLevel 5`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "file",
+						Value: "config/config.yaml",
+						Usage: "config file",
+					},
+				},
 				Action: commands.Config,
 			},
+			{
+				Name:    "routes",
+				Aliases: []string{"r"},
+				Usage:   "print routes of project",
+				UsageText: `fw routes -> print routes in table
+fw routes --format markdown > ROUTES.md -> print routes in markdown`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "table",
+						Usage: "output format: table, json or markdown",
+					},
+					&cli.StringFlag{
+						Name:    "file",
+						Aliases: []string{"f"},
+						Usage:   "astp metadata file, default is astFile in config",
+					},
+					&cli.BoolFlag{
+						Name:  "gen",
+						Usage: "regenerate metadata first",
+					},
+				},
+				Action: commands.Routes,
+			},
+			{
+				Name:  "doctor",
+				Usage: "check annotations of controllers",
+				UsageText: `fw doctor -> report problems of annotations with file:line
+fw doctor --middleware Auth,Log -> report attributes which are not one of the middlewares as well`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "file",
+						Aliases: []string{"f"},
+						Usage:   "astp metadata file, default is astFile in config",
+					},
+					&cli.StringSliceFlag{
+						Name:    "middleware",
+						Aliases: []string{"m"},
+						Usage:   "attributes of middlewares used by project",
+					},
+					&cli.BoolFlag{
+						Name:  "gen",
+						Usage: "regenerate metadata first",
+					},
+				},
+				Action: commands.Doctor,
+			},
 			{
 				Name:    "build",
 				Aliases: []string{"b"},
 				Usage:   "build project",
 				UsageText: `fw build linux amd64 -> build project for linux amd64
-fw build windows -> build project for windows amd64(default)`,
+fw build windows -> build project for windows amd64(default)
+fw build --target linux/amd64,windows/amd64 --version v1.0.0 -> build project for multiple targets`,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:    "target",
+						Aliases: []string{"t"},
+						Usage:   "os/arch to build, can be repeated or separated by comma",
+					},
+					&cli.StringFlag{
+						Name:  "version",
+						Usage: "version of app, default is `git describe --tags --always --dirty`",
+					},
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "name of binary, default is the last element of module path",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Value:   "dist",
+						Usage:   "output directory",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-gen",
+						Usage: "do not regenerate metadata",
+					},
+				},
 				Action: commands.Build,
 			},
 			{
-				Name:      "add",
-				Aliases:   []string{"a"},
-				Usage:     "add middleware/mapper/controller",
-				UsageText: `fw add middleware/mapper/controller <name> -> add middleware/mapper/controller to project`,
-				Action:    commands.Add,
+				Name:    "add",
+				Aliases: []string{"a"},
+				Usage:   "add controller/middleware/mapper/service",
+				UsageText: `fw add [flags] controller/middleware/mapper/service <name> -> add controller/middleware/mapper/service to project
+fw add --config service order -> add a service which loads config with key "order"
+fw add --key cache mapper redis -> add a mapper which loads config with key "cache"`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "key",
+						Usage: "config key, default is the name with first letter lowercased",
+					},
+					&cli.BoolFlag{
+						Name:  "config",
+						Usage: "controller/service loads config",
+					},
+					&cli.BoolFlag{
+						Name:  "inject",
+						Usage: "controller gets instances from provider",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "overwrite existing file",
+					},
+				},
+				Action: commands.Add,
 			},
 		},
 	}
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
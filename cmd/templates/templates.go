@@ -0,0 +1,7 @@
+// Package templates contains the templates used by fw commands to generate project files
+package templates
+
+import "embed"
+
+//go:embed *.tmpl
+var FS embed.FS
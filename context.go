@@ -2,6 +2,7 @@ package fw
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"github.com/gookit/goutil/errorx"
 	"github.com/linxlib/conv"
@@ -34,6 +35,7 @@ type Context struct {
 	errs       errorx.Errors
 	ErrHandler func(*Context, error)
 	hasReturn  bool // 是否已经通过上下文方法写入了返回值(包括并且不限于状态码, body, header等)
+	aborted    bool // 是否已经中止, 中止后中间件不会再调用后续的处理器
 }
 
 func newContext(ctx *fasthttp.RequestCtx, parent ...inject.Injector) *Context {
@@ -225,6 +227,35 @@ func (c *Context) Error(err error) {
 	c.String(500, err.Error())
 }
 
+// respond writes the parsed return values of a handler into response.
+// err will be written by AbortWithError, headers will be added to response,
+// and body will be written by writeResult with status unless something has been written already.
+func (c *Context) respond(body reflect.Value, status int, header http.Header, err error) {
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	c.setHeaders(header)
+	if c.hasReturn {
+		return
+	}
+	if isNilValue(body) {
+		c.Status(status)
+	} else {
+		c.writeResult(status, body.Interface())
+	}
+}
+
+// writeResult writes a value returned by controller method into response.
+// IResult will render itself, others will be rendered as json with code
+func (c *Context) writeResult(code int, obj any) {
+	if r, ok := obj.(IResult); ok {
+		r.Render(c)
+		return
+	}
+	c.JSON(code, obj)
+}
+
 func (c *Context) JSON(code int, obj any) {
 	c.render(code, render.JSON{Data: obj})
 }
@@ -284,7 +315,15 @@ func (c *Context) File(filepath string) *Context {
 func (c *Context) Protocol() string {
 	return conv.String(c.ctx.Request.Header.Protocol())
 }
+
+// HTML renders the named template loaded by Server.LoadHTMLGlob/LoadHTMLFiles/SetHTMLTemplate
 func (c *Context) HTML(code int, name string, obj any) {
+	var r render.IHTMLRender
+	if err := c.Provide(&r); err != nil || r == nil {
+		c.Error(errors.New("no html templates loaded, call LoadHTMLGlob/LoadHTMLFiles/SetHTMLTemplate first"))
+		return
+	}
+	c.render(code, r.Instance(name, obj))
 }
 func (c *Context) HTMLPure(code int, content string, obj any) *Context {
 	tmpl, _ := template.New("html").Parse(content)
@@ -481,6 +520,19 @@ func (c *Context) ContextString() string {
 	return str
 }
 
+// setHeaders adds all values in header to response headers
+func (c *Context) setHeaders(header http.Header) {
+	for key, values := range header {
+		for i, value := range values {
+			if i == 0 {
+				c.ctx.Response.Header.Set(key, value)
+			} else {
+				c.ctx.Response.Header.Add(key, value)
+			}
+		}
+	}
+}
+
 // SetHeader sets the response's HTTP header field to the specified key, value.
 func (c *Context) SetHeader(key, val string) *Context {
 	c.ctx.Response.Header.Set(key, val)
@@ -570,41 +622,81 @@ func (c *Context) ResponseBody() []byte {
 	return c.ctx.Response.Body()
 }
 
+// Abort prevents the remaining handlers of the chain from being called.
+// middlewares calling ctx.Next will skip the next handler, the response written so far will be sent.
+// Abort does not stop the current handler, so return after it.
+func (c *Context) Abort() {
+	c.aborted = true
+}
+
+// IsAborted returns true if the context has been aborted
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// AbortWithStatus writes status code and aborts
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
+// AbortWithJSON writes obj as json with code and aborts
+func (c *Context) AbortWithJSON(code int, obj any) {
+	c.JSON(code, obj)
+	c.Abort()
+}
+
+// AbortWithError writes {"error": err} with code and aborts, only code will be written if err is nil
+func (c *Context) AbortWithError(code int, err error) {
+	if err == nil {
+		c.AbortWithStatus(code)
+		return
+	}
+	c.AbortWithJSON(code, H{"error": err.Error()})
+}
+
+// Exit aborts and stops the current handler by panic.
+//
+// Deprecated: it is kept for compatibility, use Abort or AbortWithXXX and return instead.
 func (c *Context) Exit() {
-	panic("fw")
+	c.Abort()
+	panic(exitPanic)
 }
 
+// SuccessExit writes 200 and stops the current handler like Exit, use AbortWithStatus and return in new code
 func (c *Context) SuccessExit() {
-	c.SendStatus(200)
-	c.Exit()
+	c.AbortWithStatus(200)
+	panic(exitPanic)
 }
 
+// DataExit writes data as json with 200 and stops the current handler like Exit,
+// use AbortWithJSON and return in new code
 func (c *Context) DataExit(data any) {
-	c.JSON(200, data)
-	c.Exit()
+	c.AbortWithJSON(200, data)
+	panic(exitPanic)
 }
 
+// ErrorExit writes err with 500 (or 200 if err is nil) and stops the current handler like Exit,
+// use AbortWithError and return in new code
 func (c *Context) ErrorExit(err error) {
 	if err == nil {
-		c.Status(200)
-		c.Exit()
+		c.AbortWithStatus(200)
 	} else {
-		c.JSON(500, H{"error": err.Error()})
-		c.Exit()
+		c.AbortWithError(500, err)
 	}
+	panic(exitPanic)
 }
 
+// ErrorExitWithCode writes err with code and stops the current handler like Exit,
+// use AbortWithError and return in new code
 func (c *Context) ErrorExitWithCode(code int, err error) {
-	if err != nil {
-		c.JSON(code, H{"error": err.Error()})
-		c.Exit()
-	} else {
-		c.Status(code)
-		c.Exit()
-	}
-
+	c.AbortWithError(code, err)
+	panic(exitPanic)
 }
+
+// ParamErrorExit writes "key:msg" with 400 and stops the current handler like Exit,
+// use AbortWithJSON and return in new code
 func (c *Context) ParamErrorExit(key string, msg string) {
-	c.JSON(400, key+":"+msg)
-	c.Exit()
+	c.AbortWithJSON(400, key+":"+msg)
+	panic(exitPanic)
 }
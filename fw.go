@@ -1,6 +1,7 @@
 package fw
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/fasthttp/router"
@@ -9,22 +10,28 @@ import (
 	"github.com/linxlib/astp/constants"
 	types2 "github.com/linxlib/astp/types"
 	"github.com/linxlib/config"
+	"github.com/linxlib/fw/attribute"
 	"github.com/linxlib/fw/binding"
 	"github.com/linxlib/fw/inject"
 	"github.com/linxlib/fw/internal"
+	"github.com/linxlib/fw/render"
 	"github.com/linxlib/fw/types"
 	"github.com/pterm/pterm"
 	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/natefinch/lumberjack.v2"
-	"log/slog"
+	"html/template"
 	"os"
+	"os/signal"
 	"reflect"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -39,42 +46,58 @@ type HookHandler interface {
 
 type ServerOption struct {
 	IntranetIP            string
-	Dev                   bool         `yaml:"dev" default:"true"`
-	Debug                 bool         `yaml:"debug" default:"true"`
-	NoColor               bool         `yaml:"nocolor" default:"false"`
-	BasePath              string       `yaml:"basePath" default:"/"`
-	Listen                string       `yaml:"listen" default:"127.0.0.1"` //监听地址
-	Title                 string       `yaml:"title" default:"fw api"`
-	Name                  string       `yaml:"name" default:"fw"` //server_token
-	ShowRequestTimeHeader bool         `yaml:"showRequestTimeHeader,omitempty" default:"true"`
-	RequestTimeHeader     string       `yaml:"requestTimeHeader,omitempty" default:"Request-Time"`
-	Port                  int          `yaml:"port" default:"2024"`
-	AstFile               string       `yaml:"astFile" default:"gen.gz"` //ast json file generated by github.com/linxlib/astp. default is gen.json
-	Logger                LoggerOption `yaml:"logger"`
+	Dev                   bool          `yaml:"dev" default:"true"`
+	Debug                 bool          `yaml:"debug" default:"true"`
+	NoColor               bool          `yaml:"nocolor" default:"false"`
+	BasePath              string        `yaml:"basePath" default:"/"`
+	Listen                string        `yaml:"listen" default:"127.0.0.1"` //监听地址
+	Title                 string        `yaml:"title" default:"fw api"`
+	Name                  string        `yaml:"name" default:"fw"` //server_token
+	ShowRequestTimeHeader bool          `yaml:"showRequestTimeHeader,omitempty" default:"true"`
+	RequestTimeHeader     string        `yaml:"requestTimeHeader,omitempty" default:"Request-Time"`
+	Port                  int           `yaml:"port" default:"2024"`
+	AstFile               string        `yaml:"astFile" default:"gen.gz"`     //ast json file generated by github.com/linxlib/astp. default is gen.json
+	StrictRoutes          bool          `yaml:"strictRoutes" default:"false"` //collect all route conflicts and fail before the server starts
+	AutoOptions           bool          `yaml:"autoOptions" default:"true"`   //answer OPTIONS with Allow header for every path
+	AutoHead              bool          `yaml:"autoHead" default:"true"`      //serve HEAD by GET routes without body
+	WebDAV                bool          `yaml:"webdav" default:"false"`       //route WebDAV methods (PROPFIND, LOCK...), see WebDAVMethods
+	Logger                LoggerOption  `yaml:"logger"`
+	Metrics               MetricsOption `yaml:"metrics"` //used by NewMetricsMiddleware
+	Tracing               TracingOption `yaml:"tracing"` //used by NewTracingMiddleware
+	Health                HealthOption  `yaml:"health"`
+	ShutdownTimeout       int           `yaml:"shutdownTimeout" default:"30"` //seconds to wait for Shutdown on SIGINT/SIGTERM
 }
 type LoggerOption struct {
-	LoggerLevel       int    `yaml:"loggerLevel" default:"4"` //0-6 0: Panic 6: Trace
-	SeparateLevelFile bool   `yaml:"separateLevelFile" default:"false"`
-	LogDir            string `yaml:"logDir" default:"log"`
-	RotateFile        bool   `yaml:"rotate" default:"true"`
-	MaxSize           int    `yaml:"maxSize" default:"5"`
-	MaxAge            int    `yaml:"maxAge" default:"28"`
-	MaxBackups        int    `yaml:"maxBackups" default:"3"`
-	Compress          bool   `yaml:"compress" default:"false"`
-	LocalTime         bool   `yaml:"localTime" default:"true"`
+	LoggerLevel       int             `yaml:"loggerLevel" default:"4"` //0-6 0: Panic 6: Trace
+	SeparateLevelFile bool            `yaml:"separateLevelFile" default:"false"`
+	LogDir            string          `yaml:"logDir" default:"log"`
+	RotateFile        bool            `yaml:"rotate" default:"true"`
+	MaxSize           int             `yaml:"maxSize" default:"5"`
+	MaxAge            int             `yaml:"maxAge" default:"28"`
+	MaxBackups        int             `yaml:"maxBackups" default:"3"`
+	Compress          bool            `yaml:"compress" default:"false"`
+	LocalTime         bool            `yaml:"localTime" default:"true"`
+	AccessLog         AccessLogOption `yaml:"accessLog"` //used by NewAccessLogMiddleware
 }
 
+// New creates a server with config loaded from config/config.yaml (the key is optional),
+// astp metadata will be loaded from AstFile or the data registered by SetEmbeddedAST
 func New(key ...string) *Server {
+	s := newServer(key...)
+	s.loadAST()
+	return s
+}
+
+func newServer(key ...string) *Server {
 	s := &Server{
-		Injector:           inject.New(),
-		router:             router.New(),
-		server:             &fasthttp.Server{},
-		option:             new(ServerOption),
-		parser:             &astp.Parser{Project: &types2.Project{}},
-		middleware:         NewMiddlewareContainer(),
-		routerTreeForPrint: make(map[string][][2]string),
-		beginTime:          time.Now(),
-		plugins:            make([]IPlugin, 0),
+		Injector:   inject.New(),
+		router:     router.New(),
+		server:     &fasthttp.Server{},
+		option:     new(ServerOption),
+		parser:     &astp.Parser{Project: &types2.Project{}},
+		middleware: NewMiddlewareContainer(),
+		beginTime:  time.Now(),
+		plugins:    make([]IPlugin, 0),
 	}
 	s.conf = config.New(&config.Option{
 		AutoReload:         true,
@@ -98,39 +121,43 @@ func New(key ...string) *Server {
 	//pterm.DefaultHeader.WithBackgroundStyle(pterm.NewStyle(pterm.BgBlack)).WithFullWidth().Println("FW for golang developers")
 
 	s.configLogger()
+	s.configRouter()
+	if s.option.WebDAV {
+		RegisterHTTPMethod(WebDAVMethods...)
+	}
 
 	s.Map(s.option)
 	s.Map(s.conf)
 
 	s.Map(s)
-	if !internal.FileIsExist(s.option.AstFile) {
-		if s.option.Dev {
-			parser := astp.Parser{}
-			parser.Parse()
-			_ = parser.Write(s.option.AstFile)
-		} else {
-			panic(fmt.Sprintf("%s not found, please generate it first!", s.option.AstFile))
-		}
-	}
-
-	s.parser.Read(s.option.AstFile)
 	return s
 }
 
 type Server struct {
 	inject.Injector
-	server             *fasthttp.Server
-	router             *router.Router
-	option             *ServerOption
-	conf               *config.Config
-	parser             *astp.Parser
-	middleware         *MiddlewareContainer
-	logger             *logrus.Logger
-	once               sync.Once
-	midGlobals         []IMiddlewareCtl
-	routerTreeForPrint map[string][][2]string
-	beginTime          time.Time
-	plugins            []IPlugin
+	server     *fasthttp.Server
+	router     *router.Router
+	option     *ServerOption
+	conf       *config.Config
+	parser     *astp.Parser
+	middleware *MiddlewareContainer
+	logger     *logrus.Logger
+	once       sync.Once
+	midGlobals []IMiddlewareCtl
+	routes     []RouteInfo
+	panicHooks []PanicHook
+	lint       *linter
+	routeKeys  map[string]string // METHOD path -> owner, see addRoute
+	routeErrs  RouteErrors
+	beginTime  time.Time
+	plugins    []IPlugin
+	astLoaded  bool
+
+	// health checks and graceful shutdown, see health.go
+	healthMu      sync.Mutex
+	healthChecks  []HealthCheck
+	shuttingDown  atomic.Bool
+	shutdownHooks []func(ctx context.Context) error
 }
 
 type IPlugin interface {
@@ -153,6 +180,7 @@ func (s *Server) configLogger() {
 	logger.SetFormatter(Console())
 	logger.SetLevel(logrus.InfoLevel)
 	logger.SetReportCaller(true)
+	logger.AddHook(requestIDHook{})
 
 	logger.SetLevel(logrus.Level(s.option.Logger.LoggerLevel))
 	dir := s.option.Logger.LogDir
@@ -241,7 +269,21 @@ func (s *Server) RegisterRoutes(controller ...any) {
 const controllerAttr = "Controller"
 const controllerRoute = "Route"
 
+// RegisterRoute registers the methods of controller annotated with @GET, @POST... as routes.
+// a method can return values in these forms, the error is optional in all of them:
+//
+//	func(...)                              // status of @Status or 200 unless the method writes a response
+//	func(...) error
+//	func(...) (T, error)                   // T can be an IResult (View, Result...) or a value written as json
+//	func(...) (T, int, error)              // int overrides the status code (of IResult as well) when it is not 0
+//	func(...) (T, http.Header, error)      // headers are added to response
+//	func(...) (T, int, http.Header, error)
+//
+// @Status 201 changes the default status code of a method, it panics if the value is not a valid status code.
 func (s *Server) RegisterRoute(controller any) {
+	if !s.astLoaded {
+		panic(fmt.Sprintf("%s not found, please generate it first!", s.option.AstFile))
+	}
 
 	if v, ok := controller.(IController); ok {
 		v.Init(s)
@@ -265,39 +307,16 @@ func (s *Server) RegisterRoute(controller any) {
 
 	// 处理全局
 
-	s.once.Do(func() {
-		for _, plugin := range s.plugins {
-			plugin.InitPlugin(s)
-		}
-		s.midGlobals = make([]IMiddlewareCtl, 0)
-		routeItems := make([]*RouteItem, 0)
-		s.middleware.GetGlobal(func(mid IMiddlewareGlobal) bool {
-			ctx := newMiddlewareContext(mid.Name(), "", SlotGlobal, "", nil)
-			r := mid.Router(ctx)
-			if r != nil {
-				routeItems = append(routeItems, r...)
-			}
-			s.midGlobals = append(s.midGlobals, mid)
-			return false
-		})
-		for _, item := range routeItems {
-			if item.Path != "" && item.Method != "" {
-				err := s.registerRoute(item.Method, joinRoute(s.option.BasePath, item.Path, item.OverrideBasePath), item.H)
-				if err != nil {
-					panic(err)
-				}
-				if !item.IsHide {
-					s.addRouteTable("Global", item.Method, joinRoute(s.option.BasePath, item.Path, item.OverrideBasePath), item.Middleware.Name()+".H", "@"+item.Middleware.Name())
-				}
-			}
-		}
-
-	})
+	s.initGlobal()
+	if !s.option.Dev {
+		s.checkStale(refVal, typ)
+	}
 
 	// 遍历代码中所有的 @Controller 标记的结构，按照控制器对待
-	s.parser.VisitStructByName(typ.Name(), func(element *types2.Struct) bool {
-		return element.HasAttr(constants.AT_CONTROLLER) || strings.HasSuffix(element.Name, controllerAttr)
-	}, func(ctl *types2.Struct) {
+	s.parser.VisitStructByName(typ.Name(), isController, func(ctl *types2.Struct) {
+		if s.option.Dev {
+			s.lintController(ctl)
+		}
 
 		// 第一层路由 【配置文件】
 		base := s.option.BasePath
@@ -314,26 +333,31 @@ func (s *Server) RegisterRoute(controller any) {
 		ctl.SetRType(typ)
 		//处理控制器
 		middlewareCtls := make([]IMiddlewareCtl, 0)
+		ctlParams := make(map[IMiddlewareCtl]string)
 		routeItems := make([]*RouteItem, 0)
 
 		attrs1 := ctl.GetCustomAttrs()
 		for _, attr := range attrs1 {
 			if mid, ok := s.middleware.GetByAttributeCtl(strings.ToUpper(attr.CustomAttr)); ok {
-				ctx := newMiddlewareContext(ctl.Name, "", SlotController, attr.AttrValue, nil)
+				ctx := s.middlewareContext(mid, ctl.Name, "", SlotController, attr.AttrValue, nil)
 				ctx.SetRValue(ctl.GetRValue())
 				r := mid.Router(ctx)
 				if r != nil {
 					routeItems = append(routeItems, r...)
 				}
 				middlewareCtls = append(middlewareCtls, mid)
+				ctlParams[mid] = attr.AttrValue
 
 			}
 		}
+		annotationOrder(middlewareCtls, func(m IMiddlewareCtl) any { return m })
+		// @Ignore can be declared more than once on a controller as well as on a method
+		ctlIgnore := parseIgnore(ctl.GetAttrs())
 
 		for _, item := range routeItems {
 			if item.Path != "" && item.Method != "" {
-				err := s.registerRoute(item.Method, joinRoute(base, item.Path, item.OverrideBasePath), item.H)
-				if err != nil {
+				owner := ctl.Name + " @" + item.Middleware.Attribute()
+				if !s.addRoute(owner, item.Method, joinRoute(base, item.Path, item.OverrideBasePath), item.H) {
 					continue
 				}
 				if !item.IsHide {
@@ -354,96 +378,168 @@ func (s *Server) RegisterRoute(controller any) {
 			method.VisitParams(func(param *types2.Param) {
 				param.SetRType(vmt.In(param.Index))
 			})
+			if err := checkResults(vmt); err != nil {
+				s.logger.Warnf("%s.%s: %s", ctl.Name, method.Name, err)
+			}
 			// 方法的reflect.Value暂存，用于传递给中间件
 			method.SetRValue(vm)
 			method.SetValue(vm.Interface())
-			var hms = make([]string, 0)
-			var rps = make([]string, 0)
-			var toIgnore string
-			for _, attr := range method.GetAttrs() {
-				if attr.AttrType == constants.AT_POST ||
-					attr.AttrType == constants.AT_PUT ||
-					attr.AttrType == constants.AT_DELETE ||
-					attr.AttrType == constants.AT_OPTIONS ||
-					attr.AttrType == constants.AT_HEAD ||
-					attr.AttrType == constants.AT_PATCH ||
-					attr.AttrType == constants.AT_GET {
-					hms = append(hms, constants.AttrNames[attr.AttrType])
-					rps = append(rps, attr.AttrValue)
-				} else if attr.AttrType == constants.AT_IGNORE && attr.AttrValue != "" {
-					//处理忽略
-					toIgnore = strings.ToUpper(attr.AttrValue)
-				}
-			}
+			hms, rps := methodRoutes(method)
+			// 处理忽略, 方法上的 @Ignore 可以忽略控制器和全局的中间件
+			toIgnore := parseIgnore(method.GetAttrs())
 			// 先处理方法上标记的中间件
 			attrs, next := s.handle(ctl, method)
-			// 然后处理controller上的中间件
-			for _, mid := range middlewareCtls {
-
-				ctx := newMiddlewareContext(ctl.Name, method.Name, SlotMethod, "", next)
+			// 然后处理controller上的中间件, 由内向外包装, 排在前面的先执行
+			ctlAttrs := make([]string, 0, len(middlewareCtls))
+			for i := len(middlewareCtls) - 1; i >= 0; i-- {
+				mid := middlewareCtls[i]
+				ctx := s.middlewareContext(mid, ctl.Name, method.Name, SlotMethod, ctlParams[mid], next)
 				ctx.SetRValue(ctl.GetRValue())
 				// 如果方法上打了 @Ignore Auth 则需要忽略 Auth这个代表 AuthMiddleware 的中间件
-				//TODO: 是否需要处理 @Ignore 多个的情况？
-				if toIgnore == strings.ToUpper(mid.Attribute()) {
-					ctx.Ignored = true
-				} else {
-					attrs = append(attrs, mid.Attribute())
-				}
+				ctx.Ignored = toIgnore.has(mid.Attribute())
+				ctlAttrs = append([]string{ignoredName("@"+mid.Attribute(), ctx.Ignored)}, ctlAttrs...)
 				next = mid.Execute(ctx)
 			}
 			// 这里全局的中间件 仅针对于方法，不会对Controller做出改变
-			for _, global := range s.midGlobals {
-				ctx := newMiddlewareContext(global.Name(), "", SlotGlobal, "", next)
-				next = global.Execute(ctx)
+			// 控制器上的 @Ignore 只能忽略全局的中间件
+			next = s.withGlobals(ctl.Name, method.Name, next, ctlIgnore, toIgnore)
+			chain := append(append(s.globalChain(ctlIgnore, toIgnore), ctlAttrs...), attrs...)
+			for i, hm := range hms {
+				route := joinRoute(base, rps[i])
+				if !s.addRoute(ctl.Name+"."+method.Name, hm, route, next) {
+					continue
+				}
+				//if method.FromParent {
+				//	if sig.Len() != 0 {
+				//		sig.WriteRune(',')
+				//	}
+				//
+				//	sig.WriteString("@inherit")
+				//}
+				s.addRouteTable(method.Receiver.Type, hm, route, method.Name, chain...)
 			}
 
-			sig := strings.Builder{}
-			for i, attr := range attrs {
-				if i != 0 {
-					sig.WriteRune(',')
-				}
-				sig.WriteString("@")
-				sig.WriteString(attr)
+		})
+
+	})
+}
+
+// lintController reports problems of the annotations of ctl in Dev, like `fw doctor` does
+func (s *Server) lintController(ctl *types2.Struct) {
+	if s.lint == nil {
+		s.lint = newLinter(s.option.BasePath, sourcePositions("."), func(attr string, slot SlotType) bool {
+			attr = strings.ToUpper(attr)
+			if slot == SlotController {
+				_, ok := s.middleware.GetByAttributeCtl(attr)
+				return ok
 			}
-			if len(hms) <= 0 {
-				a, b, ok := handleDefaultMethodRoute(method.Name, !method.Private)
-				if ok {
-					s.registerRoute(a, joinRoute(base, b), next)
-					receiver := method.Receiver
-					controllerName := receiver.Type
-					route := joinRoute(base, b)
-					//if method.FromParent {
-					//	if sig.Len() != 0 {
-					//		sig.WriteRune(',')
-					//	}
-					//
-					//	sig.WriteString("@inherit")
-					//}
-					s.addRouteTable(controllerName, a, route, method.Name, sig.String())
-				}
+			if slot == SlotGlobal {
+				return s.middleware.VisitAll(SlotGlobal, func(mid IMiddleware) bool {
+					return strings.EqualFold(mid.Name(), attr)
+				})
+			}
+			_, ok := s.middleware.GetByAttributeMethod(attr)
+			return ok
+		})
+		// duplicate routes are reported by addRoute
+		s.lint.checkRoutes = false
+	}
+	for _, d := range s.lint.checkController(ctl) {
+		s.logger.Error(d.String())
+	}
+}
 
-			} else {
-				for i, hm := range hms {
-					err := s.registerRoute(strings.ToUpper(hm), joinRoute(base, rps[i]), next)
-					if err != nil {
-						continue
-					}
-					receiver := method.Receiver
-					controllerName := receiver.Type
-					route := joinRoute(base, rps[i])
-					//if method.FromParent {
-					//	if sig.Len() != 0 {
-					//		sig.WriteRune(',')
-					//	}
-					//
-					//	sig.WriteString("@inherit")
-					//}
-					s.addRouteTable(controllerName, strings.ToUpper(hm), route, method.Name, sig.String())
-				}
+// checkStale warns when the controller or its exported methods are missing in astp metadata,
+// which means the metadata is out of date and should be regenerated
+func (s *Server) checkStale(controller reflect.Value, typ reflect.Type) {
+	found := false
+	names := make(map[string]bool)
+	s.parser.VisitStructByName(typ.Name(), func(element *types2.Struct) bool {
+		return true
+	}, func(ctl *types2.Struct) {
+		found = true
+		ctl.VisitMethods(func(method *types2.Function) bool {
+			names[method.Name] = true
+			return false
+		}, func(method *types2.Function) {})
+	})
+	if !found {
+		s.logger.Warnf("controller %s not found in %s, please regenerate it", typ.Name(), s.option.AstFile)
+		return
+	}
+	if missing := staleMethods(controller.Type(), names); len(missing) > 0 {
+		s.logger.Warnf("methods %s of controller %s not found in %s, please regenerate it",
+			strings.Join(missing, ","), typ.Name(), s.option.AstFile)
+	}
+}
+
+// frameworkMethods are methods of IController and IControllerConfig, they are not routes
+var frameworkMethods = map[string]bool{"Init": true, "InitConfig": true}
+
+// staleMethods returns the exported methods declared on controller type t which are not in names.
+// methods promoted from embedded fields and those called by the framework (Init, InitConfig) are skipped
+func staleMethods(t reflect.Type, names map[string]bool) []string {
+	st := t
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	promoted := make(map[string]bool)
+	if st.Kind() == reflect.Struct {
+		for i := 0; i < st.NumField(); i++ {
+			f := st.Field(i)
+			if !f.Anonymous {
+				continue
+			}
+			ft := f.Type
+			if ft.Kind() != reflect.Pointer && ft.Kind() != reflect.Interface {
+				ft = reflect.PointerTo(ft)
+			}
+			for j := 0; j < ft.NumMethod(); j++ {
+				promoted[ft.Method(j).Name] = true
 			}
+		}
+	}
+	missing := make([]string, 0)
+	for i := 0; i < t.NumMethod(); i++ {
+		name := t.Method(i).Name
+		if names[name] || promoted[name] || frameworkMethods[name] {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
 
+// initGlobal initializes plugins and global middlewares (and routes provided by them) only once
+func (s *Server) initGlobal() {
+	s.once.Do(func() {
+		for _, plugin := range s.plugins {
+			plugin.InitPlugin(s)
+		}
+		s.midGlobals = make([]IMiddlewareCtl, 0)
+		routeItems := make([]*RouteItem, 0)
+		s.middleware.GetGlobal(func(mid IMiddlewareGlobal) bool {
+			ctx := newMiddlewareContext(mid.Name(), "", SlotGlobal, "", nil)
+			r := mid.Router(ctx)
+			if r != nil {
+				routeItems = append(routeItems, r...)
+			}
+			s.midGlobals = append(s.midGlobals, mid)
+			return false
 		})
-
+		for _, item := range routeItems {
+			if item.Path != "" && item.Method != "" {
+				if !s.addRoute("@"+item.Middleware.Name(), item.Method, joinRoute(s.option.BasePath, item.Path, item.OverrideBasePath), item.H) {
+					continue
+				}
+				if !item.IsHide {
+					s.addRouteTable("Global", item.Method, joinRoute(s.option.BasePath, item.Path, item.OverrideBasePath), item.Middleware.Name()+".H", "@"+item.Middleware.Name())
+				}
+			}
+		}
+		s.healthRoutes()
+		// preflight requests go through global middlewares (e.g. cors)
+		s.router.GlobalOPTIONS = s.wrap(s.withGlobals("", "", s.autoOptions))
 	})
 }
 
@@ -467,7 +563,11 @@ func (s *Server) registerRoute(method string, path string, f HandlerFunc) error
 	case "ANY", "WS":
 		s.router.ANY(path, call1)
 	default:
-		return fmt.Errorf("http method:[%v -> %s] not supported", method, path)
+		// TRACE, CONNECT and custom methods registered by RegisterHTTPMethod
+		if !attribute.IsHttpMethod(method) {
+			return fmt.Errorf("http method:[%v -> %s] not supported", method, path)
+		}
+		s.router.Handle(method, path, call1)
 	}
 
 	return nil
@@ -495,7 +595,7 @@ func handleDefaultMethodRoute(name string, isPublic bool) (method, path string,
 	re := regexp.MustCompile("([a-z0-9])([A-Z])")
 	snakeCase := re.ReplaceAllString(methodName, `${1}_${2}`)
 	snakeCase = strings.ToLower(snakeCase) // 转为小写
-	return methodName, "/" + snakeCase, true
+	return method, "/" + snakeCase, true
 }
 
 func (s *Server) bind(c *Context, handler *types2.Function) error {
@@ -530,21 +630,7 @@ func (s *Server) bind(c *Context, handler *types2.Function) error {
 		// 对方法参数进行数据映射和校验
 		binder := binding.GetByAttr(param.Struct.GetAttr())
 		if binding.IsBodyBinder(binder) {
-			if c.Method() == "POST" || c.Method() == "PUT" || c.Method() == "PUT" || c.Method() == "PATCH" || c.Method() == "DELETE" {
-				switch c.GetHeader("Content-Type") {
-				case "application/json":
-					binder = binding.JSON
-				case "application/xml":
-					binder = binding.XML
-				case "application/x-www-form-urlencoded":
-					binder = binding.Form
-				case "multipart/form-data":
-					binder = binding.FormMultipart
-				case "text/plain":
-					binder = binding.Plain
-
-				}
-			}
+			binder = bodyBinding(c, binder)
 		}
 
 		if err := binder.Bind(c.GetFastContext(), paramV.Interface()); err != nil {
@@ -560,101 +646,161 @@ func (s *Server) bind(c *Context, handler *types2.Function) error {
 
 	return nil
 }
-func callers(skip int) {
-	pcs := make([]uintptr, 1024)
-	runtime.Callers(skip+2, pcs)
-	frames := runtime.CallersFrames(pcs)
-	for {
-		frame, more := frames.Next()
-		fmt.Println(frame.File+":"+strconv.Itoa(frame.Line), frame.Function)
-		if !more {
-			break
-		}
+
+// bodyBinding returns the binding for request body according to the Content-Type header.
+// def will be returned if the request method can not have body or the Content-Type is unknown
+func bodyBinding(c *Context, def binding.Binding) binding.Binding {
+	switch c.Method() {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "CONNECT":
+		return def
+	}
+	contentType, _, _ := strings.Cut(c.GetHeader("Content-Type"), ";")
+	switch strings.TrimSpace(contentType) {
+	case binding.MIMEJSON:
+		return binding.JSON
+	case binding.MIMEXML, binding.MIMEXML2:
+		return binding.XML
+	case binding.MIMEPOSTForm:
+		return binding.Form
+	case binding.MIMEMultipartPOSTForm:
+		return binding.FormMultipart
+	case binding.MIMEPlain:
+		return binding.Plain
 	}
+	return def
 }
-func (s *Server) wrapM(handler *types2.Function) HandlerFunc {
-	return func(context *Context) {
-		defer func() {
-			if err := recover(); err != nil {
-
-				switch err1 := err.(type) {
-				case error:
-					slog.Error(err1.Error())
-					callers(2)
-					//panic(err1)
-					//context.Error(err1.(error))
-				case string:
-					if err != "fw" {
-						panic(err1)
-					}
-				}
 
-			}
-		}()
+// statusCode returns the default status code of target, it is 200 unless @Status is given in attrs.
+// it panics on a malformed @Status so that the server will not start
+func statusCode(target string, attrs []*types2.Comment) int {
+	v, ok := getCustomAttrValue(attrs, attrStatus)
+	if !ok {
+		return 200
+	}
+	code, err := strconv.Atoi(v)
+	if err != nil || code < 100 || code > 599 {
+		panic(fmt.Sprintf("%s: @Status %s: should be a status code between 100 and 599", target, v))
+	}
+	return code
+}
+
+func (s *Server) wrapM(ctlName string, handler *types2.Function) HandlerFunc {
+	// @Status 201 changes the default status code for success
+	code := statusCode(ctlName+"."+handler.Name, handler.GetCustomAttrs())
+	return func(context *Context) {
+		defer s.recover(context)
 		var err error
 		// binding params
+		span := context.startPhase("bind")
 		err = s.bind(context, handler)
+		endPhase(span, err)
+		// bind and validation errors are caused by client input
 		if err != nil {
-			context.ErrorExit(err)
+			context.AbortWithError(400, err)
+			return
 		}
 		// call method
-
-		values, err := context.inj.Invoke(handler.GetValue())
+		span = context.startPhase("handler " + handler.Name)
+		values, err := s.invoke(context, handler, span)
 		if err != nil {
 			panic(err)
 		}
-		last := len(values) - 1
-		if last == -1 { // if there is no return value, just skip.
+		if len(values) == 0 { // if there is no return value, just skip.
 			if !context.hasReturn {
-				context.SendStatus(200)
+				context.SendStatus(code)
 			}
 			return
 		}
+		// only the first return value will be written into response body
+		// an IResult (View, Result...) will render itself, others will be treated as json
+		context.respond(parseResults(values, code))
+	}
+}
 
-		if err := values[last]; !err.IsNil() {
-			// if the last return value is error, parse it and write error info into response body
-			if e, ok := err.Interface().(error); ok {
-				context.ErrorExit(e)
-			} else { // If there is no error return value, the return value will be treated as a normal return.
-				// and only one return value will be written into response body
-				if !context.hasReturn {
-					context.JSON(200, values[0].Interface())
-				}
-			}
-		} else {
-			// method returns error, just ignore others.
-			if !context.hasReturn {
-				if values[0].IsNil() {
-					context.Status(200)
-				} else {
-					context.JSON(200, values[0].Interface())
-				}
+// invoke calls handler, span will be ended with the error returned by handler
+func (s *Server) invoke(c *Context, handler *types2.Function, span trace.Span) (values []reflect.Value, err error) {
+	defer func() {
+		if span.IsRecording() {
+			_, _, _, e := parseResults(values, 0)
+			endPhase(span, e)
+		}
+	}()
+	return c.inj.Invoke(handler.GetValue())
+}
 
-			}
-			//context.Error(err.Interface().(error))
+// getCustomAttrValue returns the value of custom attribute name (case-insensitive)
+func getCustomAttrValue(attrs []*types2.Comment, name string) (string, bool) {
+	for _, attr := range attrs {
+		if strings.EqualFold(attr.CustomAttr, name) {
+			return strings.TrimSpace(attr.AttrValue), true
 		}
 	}
+	return "", false
 }
 
+// handle wraps handler with its method middlewares, it returns the attributes of them in the order they run
 func (s *Server) handle(ctl *types2.Struct, handler *types2.Function) ([]string, HandlerFunc) {
 	//先把实际的方法wrap成HandlerFunc
-	next := s.wrapM(handler)
+	next := s.wrapM(ctl.Name, handler)
 	// 先处理method上的中间件
-	attrs := handler.GetCustomAttrs()
-	var attrs1 []string
-	for _, attr := range attrs {
+	type methodMiddleware struct {
+		IMiddlewareMethod
+		param string
+	}
+	mids := make([]methodMiddleware, 0)
+	for _, attr := range handler.GetCustomAttrs() {
 		if mid, ok := s.middleware.GetByAttributeMethod(strings.ToUpper(attr.CustomAttr)); ok {
-			attrs1 = append(attrs1, mid.Attribute())
-
-			ctx := newMiddlewareContext(ctl.Name, handler.Name, SlotMethod, attr.AttrValue, next)
-			ctx.SetRValue(ctl.GetRValue())
-			next = mid.Execute(ctx)
+			mids = append(mids, methodMiddleware{IMiddlewareMethod: mid, param: attr.AttrValue})
 		}
 	}
+	annotationOrder(mids, func(m methodMiddleware) any { return m.IMiddlewareMethod })
+	attrs1 := make([]string, len(mids))
+	for i := len(mids) - 1; i >= 0; i-- {
+		attrs1[i] = "@" + mids[i].Attribute()
+		ctx := s.middlewareContext(mids[i].IMiddlewareMethod, ctl.Name, handler.Name, SlotMethod, mids[i].param, next)
+		ctx.SetRValue(ctl.GetRValue())
+		next = mids[i].Execute(ctx)
+	}
 	return attrs1, next
 }
 
-func (s *Server) addRouteTable(controllerName, method, routePath, methodName, signature string) {
+// middlewareContext creates the context of mid and decodes its typed params (see IParams),
+// it panics on malformed annotations so that the server will not start
+func (s *Server) middlewareContext(mid IMiddlewareBase, ctlName, methodName string, location SlotType, param string, next HandlerFunc) *MiddlewareContext {
+	ctx := newMiddlewareContext(ctlName, methodName, location, param, next)
+	if err := ctx.decodeParams(mid); err != nil {
+		target := ctlName
+		if methodName != "" {
+			target += "." + methodName
+		}
+		panic(fmt.Sprintf("%s: @%s %s: %v", target, mid.Attribute(), strings.TrimSpace(param), err))
+	}
+	return ctx
+}
+
+// addRouteTable records a route for the route table, middlewares are in the order they run
+func (s *Server) addRouteTable(controllerName, method, routePath, methodName string, middlewares ...string) {
+	route := RouteInfo{
+		Controller: controllerName,
+		Method:     method,
+		Path:       routePath,
+		Handler:    methodName,
+	}
+	if len(middlewares) > 0 {
+		route.Middlewares = middlewares
+	}
+	s.routes = append(s.routes, route)
+}
+
+// Routes returns the routes registered so far, including routes provided by middlewares and Handle.
+// hidden routes are not included
+func (s *Server) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(s.routes))
+	copy(routes, s.routes)
+	return routes
+}
+
+func (s *Server) printRoute() {
 	var fcolor1 = func(method string) string {
 		switch method {
 		case "GET":
@@ -676,44 +822,22 @@ func (s *Server) addRouteTable(controllerName, method, routePath, methodName, si
 		}
 	}
 	const itemFmt = "%-16s %-30s%-30s"
-	controllerName = color.Magenta.Sprint(controllerName)
-	if v, ok := s.routerTreeForPrint[controllerName]; ok {
-		var temp [2]string
-		temp[0] = color.HiYellow.Sprint(signature)
-		if methodName == "" {
-			temp[1] = fmt.Sprintf(itemFmt, fcolor1(method), routePath, color.HiGreen.Sprint(""))
-		} else {
-			temp[1] = fmt.Sprintf(itemFmt, fcolor1(method), routePath, "-> "+color.HiGreen.Sprint(methodName))
-		}
-
-		v = append(v, temp)
-		s.routerTreeForPrint[controllerName] = v
-	} else {
-		s.routerTreeForPrint[controllerName] = make([][2]string, 0)
-		var temp [2]string
-		temp[0] = color.HiYellow.Sprint(signature)
-		if methodName == "" {
-			temp[1] = fmt.Sprintf(itemFmt, fcolor1(method), routePath, color.HiGreen.Sprint(""))
-		} else {
-			temp[1] = fmt.Sprintf(itemFmt, fcolor1(method), routePath, "-> "+color.HiGreen.Sprint(methodName))
-		}
-		s.routerTreeForPrint[controllerName] = append(s.routerTreeForPrint[controllerName], temp)
-	}
-}
-
-func (s *Server) printRoute() {
 	var node = pterm.TreeNode{
 		Text: "FW Server",
 	}
-
-	for s2, i := range s.routerTreeForPrint {
+	for _, group := range groupRoutes(s.routes) {
 		no := pterm.TreeNode{
-			Text: s2,
+			Text: color.Magenta.Sprint(group[0].Controller),
 		}
-		for _, i3 := range i {
+		for _, route := range group {
+			handler := ""
+			if route.Handler != "" {
+				handler = "-> " + color.HiGreen.Sprint(route.Handler)
+			}
 			no.Children = append(no.Children,
 				pterm.TreeNode{
-					Text: i3[1] + " " + i3[0],
+					Text: fmt.Sprintf(itemFmt, fcolor1(route.Method), route.Path, handler) + " " +
+						color.HiYellow.Sprint(strings.Join(route.Middlewares, " > ")),
 				})
 		}
 		node.Children = append(node.Children, no)
@@ -727,10 +851,16 @@ func (s *Server) printInfo() {
 	style2 := pterm.NewStyle(pterm.FgDarkGray)
 	style3 := pterm.NewStyle(pterm.FgLightWhite, pterm.Bold)
 	style4 := pterm.NewStyle(pterm.FgWhite)
+	info := GetBuildInfo()
 	style.Print("FW ")
-	style1.Print(Version + " ")
+	style1.Print(info.Version + " ")
 	style2.Print("ready in ")
 	style3.Println(time.Now().Sub(s.beginTime).String())
+	if info.Commit != "" {
+		style.Print("  ➜ ")
+		style3.Printf("%10s", "Build: ")
+		style4.Println(strings.TrimSpace(info.Commit + " " + info.BuildTime))
+	}
 
 	//color.Printf("%s %s %s\n", color.HiGreen.Sprintf("FW %s", Version), color.Gray.Sprint("ready in"), color.HiWhite.Sprint("568ms"))
 	style.Print("  ➜ ")
@@ -773,6 +903,11 @@ func (s *Server) start() chan bool {
 		}
 	}
 
+	s.initGlobal()
+	if err := s.CheckRoutes(); err != nil && s.option.StrictRoutes {
+		s.logger.Error(err.Error())
+		panic(err)
+	}
 	s.printRoute()
 
 	s.server.Handler = s.router.Handler
@@ -781,11 +916,10 @@ func (s *Server) start() chan bool {
 
 	done := make(chan bool)
 	go func() {
+		defer close(done)
 		err := s.server.ListenAndServe(fmt.Sprintf("%s:%d", s.option.Listen, s.option.Port))
 		if err != nil {
 			internal.Errorf("Failed to start server: %v", err)
-			done <- true
-			return
 		}
 	}()
 
@@ -805,13 +939,30 @@ func (s *Server) BasePath() string {
 	return strings.TrimSuffix(s.option.BasePath, "/")
 }
 
+// Handler returns the request handler of server, which can be used in tests without listening
+func (s *Server) Handler() fasthttp.RequestHandler {
+	return s.router.Handler
+}
+
+// Start runs the server and blocks until it stops, SIGINT or SIGTERM shuts it down gracefully (see Shutdown)
 func (s *Server) Start() {
 	done := s.start()
-	for {
-		select {
-		case <-done:
-			return
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	select {
+	case <-done:
+	case <-quit:
+		timeout := time.Duration(s.option.ShutdownTimeout) * time.Second
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			s.logger.Errorf("shutdown: %v", err)
 		}
+		<-done
 	}
 }
 
@@ -835,6 +986,35 @@ func (s *Server) Use(middleware ...IMiddleware) {
 
 }
 
+// LoadHTMLGlob loads html templates matched by pattern for Context.HTML and View results.
+// templates will be reloaded on every request in Dev mode
+func (s *Server) LoadHTMLGlob(pattern string) {
+	if s.option.Dev {
+		s.setHTMLRender(render.HTMLDebug{Glob: pattern})
+		return
+	}
+	s.SetHTMLTemplate(template.Must(template.New("").ParseGlob(pattern)))
+}
+
+// LoadHTMLFiles loads html templates from files for Context.HTML and View results.
+// templates will be reloaded on every request in Dev mode
+func (s *Server) LoadHTMLFiles(files ...string) {
+	if s.option.Dev {
+		s.setHTMLRender(render.HTMLDebug{Files: files})
+		return
+	}
+	s.SetHTMLTemplate(template.Must(template.New("").ParseFiles(files...)))
+}
+
+// SetHTMLTemplate uses tmpl for Context.HTML and View results
+func (s *Server) SetHTMLTemplate(tmpl *template.Template) {
+	s.setHTMLRender(render.HTMLProduction{Template: tmpl})
+}
+
+func (s *Server) setHTMLRender(r render.IHTMLRender) {
+	s.MapTo(r, (*render.IHTMLRender)(nil))
+}
+
 func (s *Server) UseMapper(mapper ...ServiceMapper) {
 	if len(mapper) <= 0 {
 		return
@@ -845,6 +1025,7 @@ func (s *Server) UseMapper(mapper ...ServiceMapper) {
 			panic(err)
 		}
 		s.Map(result)
+		s.useHealthChecker(result)
 	}
 
 }
@@ -856,6 +1037,7 @@ func (s *Server) UseService(service ...IService) {
 	for _, iService := range service {
 		iService.Init(s)
 		s.Map(iService)
+		s.useHealthChecker(iService)
 	}
 
 }
@@ -867,6 +1049,7 @@ func (s *Server) UseServiceWithConfig(service ...IServiceConfig) {
 	for _, serviceConfig := range service {
 		serviceConfig.InitConfig(s.conf)
 		s.Map(serviceConfig)
+		s.useHealthChecker(serviceConfig)
 	}
 
 }
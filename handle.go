@@ -0,0 +1,60 @@
+package fw
+
+import (
+	"fmt"
+	"github.com/linxlib/fw/binding"
+	"reflect"
+	"strings"
+)
+
+// Handle registers a typed handler for method and path without astp metadata.
+//
+// Req will be bound by its struct tags and then validated:
+// fields tagged with `path`, `query`, `header`, `cookie` or `form` are mapped from the corresponding source,
+// the others are decoded from body according to the Content-Type (json by default).
+// the result will be written like the return values of a controller method,
+// so Resp can be an IResult as well.
+//
+//	type CreateUser struct {
+//		Org  string `path:"org"`
+//		Name string `json:"name" validate:"required"`
+//	}
+//	fw.Handle(s, "POST", "/orgs/{org}/users", func(c *fw.Context, req *CreateUser) (*User, error) {
+//		...
+//	})
+//
+// global middlewares will be applied to the handler as well.
+func Handle[Req any, Resp any](s *Server, method string, path string, h func(*Context, *Req) (*Resp, error)) {
+	method = strings.ToUpper(method)
+	route := joinRoute(s.option.BasePath, path)
+	reqType := reflect.TypeOf((*Req)(nil)).Elem()
+	hasBody := binding.HasBodyTags(reqType)
+
+	var next HandlerFunc = func(c *Context) {
+		defer s.recover(c)
+		req := new(Req)
+		var body binding.Binding
+		if hasBody && len(c.PostBody()) > 0 {
+			body = bodyBinding(c, binding.JSON)
+		}
+		span := c.startPhase("bind")
+		err := binding.BindByTags(c.GetFastContext(), req, body)
+		endPhase(span, err)
+		// bind and validation errors are caused by client input
+		if err != nil {
+			c.AbortWithError(400, err)
+			return
+		}
+		span = c.startPhase("handler")
+		resp, err := h(c, req)
+		endPhase(span, err)
+		c.respond(reflect.ValueOf(resp), 200, nil, err)
+	}
+
+	s.initGlobal()
+	next = s.withGlobals("Handle", "", next)
+	handler := fmt.Sprintf("func(*%s)", reqType.Name())
+	if s.addRoute("Handle "+handler, method, route, next) {
+		s.addRouteTable("Handle", method, route, handler, s.globalChain()...)
+	}
+}
@@ -0,0 +1,194 @@
+package fw
+
+import (
+	"errors"
+	"github.com/fasthttp/router"
+	"github.com/linxlib/fw/inject"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+// newTestServer creates a server without config file and astp metadata
+func newTestServer() *Server {
+	s := &Server{
+		Injector:   inject.New(),
+		router:     router.New(),
+		option:     &ServerOption{BasePath: "/"},
+		middleware: NewMiddlewareContainer(),
+	}
+	s.configRouter()
+	return s
+}
+
+type testCreateUser struct {
+	Org   string `path:"org"`
+	Token string `header:"X-Token"`
+	Name  string `json:"name" validate:"required"`
+}
+
+type testUser struct {
+	Org  string `json:"org"`
+	Name string `json:"name"`
+}
+
+func TestHandle(t *testing.T) {
+	s := newTestServer()
+	Handle(s, "POST", "/orgs/{org}/users", func(c *Context, req *testCreateUser) (*testUser, error) {
+		if req.Token != "secret" {
+			return nil, errors.New("forbidden")
+		}
+		return &testUser{Org: req.Org, Name: req.Name}, nil
+	})
+
+	tests := []struct {
+		name       string
+		uri        string
+		body       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "ok",
+			uri:        "http://localhost/orgs/fw/users",
+			body:       `{"name":"linx"}`,
+			token:      "secret",
+			wantStatus: 200,
+			wantBody:   `{"org":"fw","name":"linx"}`,
+		},
+		{
+			name:       "validate",
+			uri:        "http://localhost/orgs/fw/users",
+			body:       `{}`,
+			token:      "secret",
+			wantStatus: 400,
+		},
+		{
+			name:       "malformed body",
+			uri:        "http://localhost/orgs/fw/users",
+			body:       `{"name":`,
+			token:      "secret",
+			wantStatus: 400,
+		},
+		{
+			name:       "error",
+			uri:        "http://localhost/orgs/fw/users",
+			body:       `{"name":"linx"}`,
+			wantStatus: 500,
+			wantBody:   `{"error":"forbidden"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod("POST")
+			ctx.Request.SetRequestURI(tt.uri)
+			ctx.Request.Header.SetContentType("application/json; charset=utf-8")
+			ctx.Request.Header.Set("X-Token", tt.token)
+			ctx.Request.SetBodyString(tt.body)
+			s.Handler()(ctx)
+			if got := ctx.Response.StatusCode(); got != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := string(ctx.Response.Body()); got != tt.wantBody {
+					t.Errorf("body = %v, want %v", got, tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+type authMiddleware struct {
+	*MiddlewareGlobal
+}
+
+func (a *authMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	return func(c *Context) {
+		if c.GetHeader("X-Token") != "secret" {
+			c.AbortWithError(401, errors.New("unauthorized"))
+		}
+		// next will be skipped after Abort
+		ctx.Next(c)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	s := newTestServer()
+	s.initGlobal()
+	s.midGlobals = []IMiddlewareCtl{&authMiddleware{NewMiddlewareGlobal("Auth")}}
+	var called bool
+	Handle(s, "GET", "/me", func(c *Context, req *struct{}) (*testUser, error) {
+		called = true
+		return &testUser{Name: "linx"}, nil
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantBody   string
+		wantCalled bool
+	}{
+		{name: "aborted", wantStatus: 401, wantBody: `{"error":"unauthorized"}`},
+		{name: "ok", token: "secret", wantStatus: 200, wantBody: `{"org":"","name":"linx"}`, wantCalled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost/me")
+			ctx.Request.Header.Set("X-Token", tt.token)
+			s.Handler()(ctx)
+			if ctx.Response.StatusCode() != tt.wantStatus {
+				t.Errorf("status = %d, want %d", ctx.Response.StatusCode(), tt.wantStatus)
+			}
+			if string(ctx.Response.Body()) != tt.wantBody {
+				t.Errorf("body = %s, want %s", ctx.Response.Body(), tt.wantBody)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("called = %v, want %v", called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+func TestExitHelpers(t *testing.T) {
+	tests := []struct {
+		name       string
+		exit       func(c *Context)
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "success", exit: (*Context).SuccessExit, wantStatus: 200},
+		{name: "data", exit: func(c *Context) { c.DataExit(H{"id": 1}) }, wantStatus: 200, wantBody: `{"id":1}`},
+		{name: "error", exit: func(c *Context) { c.ErrorExit(errors.New("failed")) }, wantStatus: 500, wantBody: `{"error":"failed"}`},
+		{name: "nil error", exit: func(c *Context) { c.ErrorExit(nil) }, wantStatus: 200},
+		{name: "error with code", exit: func(c *Context) { c.ErrorExitWithCode(403, errors.New("forbidden")) }, wantStatus: 403, wantBody: `{"error":"forbidden"}`},
+		{name: "param error", exit: func(c *Context) { c.ParamErrorExit("id", "required") }, wantStatus: 400, wantBody: `"id:required"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			stopped := true
+			Handle(s, "GET", "/", func(c *Context, req *struct{}) (*testUser, error) {
+				tt.exit(c)
+				// existing callers rely on the helpers to stop the handler
+				stopped = false
+				return &testUser{}, nil
+			})
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost/")
+			s.Handler()(ctx)
+			if !stopped {
+				t.Error("handler should be stopped")
+			}
+			if ctx.Response.StatusCode() != tt.wantStatus {
+				t.Errorf("status = %d, want %d", ctx.Response.StatusCode(), tt.wantStatus)
+			}
+			if tt.wantBody != "" && string(ctx.Response.Body()) != tt.wantBody {
+				t.Errorf("body = %s, want %s", ctx.Response.Body(), tt.wantBody)
+			}
+		})
+	}
+}
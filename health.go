@@ -0,0 +1,244 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	healthUp           = "up"
+	healthDown         = "down"
+	healthShuttingDown = "shutting down"
+)
+
+// HealthOption configures the health endpoints, it lives under `health`.
+// the endpoints are registered outside `basePath`, so user routes on the same paths conflict with them
+// and fail to start when `strictRoutes` is on.
+type HealthOption struct {
+	Enable     bool   `yaml:"enable" default:"false"`
+	Path       string `yaml:"path" default:"/healthz"`     //runs all checks, base path is not prepended
+	ReadyPath  string `yaml:"readyPath" default:"/readyz"` //runs all checks and fails during shutdown
+	LivePath   string `yaml:"livePath" default:"/livez"`   //always up while the process is serving
+	Timeout    int    `yaml:"timeout" default:"3"`         //seconds, default timeout of a check
+	DrainDelay int    `yaml:"drainDelay" default:"5"`      //seconds to keep serving after readiness fails on shutdown, only if enabled
+}
+
+// HealthCheck is a named check of a dependency, e.g. ping of database or cache.
+// the check fails if it returns an error or does not return in Timeout (`health.timeout` if zero)
+type HealthCheck struct {
+	Name    string
+	Check   func(ctx context.Context) error
+	Timeout time.Duration
+}
+
+// IHealthChecker can be implemented by services and results of ServiceMapper,
+// their checks will be registered by UseService, UseServiceWithConfig and UseMapper
+//
+//	func (s *OrderService) HealthChecks() []fw.HealthCheck {
+//		return []fw.HealthCheck{{Name: "db", Check: s.db.PingContext, Timeout: time.Second}}
+//	}
+type IHealthChecker interface {
+	HealthChecks() []HealthCheck
+}
+
+// HealthResult is the result of a check
+type HealthResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthStatus is the aggregated status written by health endpoints
+type HealthStatus struct {
+	Status string                  `json:"status"`
+	Checks map[string]HealthResult `json:"checks,omitempty"`
+}
+
+// AddHealthCheck registers a named check for /healthz and /readyz, timeout overrides `health.timeout`
+func (s *Server) AddHealthCheck(name string, check func(ctx context.Context) error, timeout ...time.Duration) {
+	hc := HealthCheck{Name: name, Check: check}
+	if len(timeout) > 0 {
+		hc.Timeout = timeout[0]
+	}
+	s.addHealthChecks(hc)
+}
+
+func (s *Server) addHealthChecks(checks ...HealthCheck) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	for _, check := range checks {
+		if check.Name == "" || check.Check == nil {
+			panic("health check should have a name and a check func")
+		}
+		for _, hc := range s.healthChecks {
+			if hc.Name == check.Name {
+				panic(fmt.Sprintf("health check %s registered twice", check.Name))
+			}
+		}
+		s.healthChecks = append(s.healthChecks, check)
+	}
+}
+
+// useHealthChecker registers checks of v if it implements IHealthChecker
+func (s *Server) useHealthChecker(v any) {
+	if checker, ok := v.(IHealthChecker); ok {
+		s.addHealthChecks(checker.HealthChecks()...)
+	}
+}
+
+// Health runs all checks concurrently and returns the aggregated status
+func (s *Server) Health(ctx context.Context) *HealthStatus {
+	s.healthMu.Lock()
+	checks := append([]HealthCheck(nil), s.healthChecks...)
+	s.healthMu.Unlock()
+
+	status := &HealthStatus{Status: healthUp}
+	if len(checks) == 0 {
+		return status
+	}
+	results := make([]HealthResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.runHealthCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+	status.Checks = make(map[string]HealthResult, len(checks))
+	for i, check := range checks {
+		status.Checks[check.Name] = results[i]
+		if results[i].Status != healthUp {
+			status.Status = healthDown
+		}
+	}
+	return status
+}
+
+// runHealthCheck runs check with its timeout, a check which ignores ctx is abandoned after timeout
+func (s *Server) runHealthCheck(ctx context.Context, check HealthCheck) HealthResult {
+	timeout := check.Timeout
+	if timeout <= 0 && s.option != nil && s.option.Health.Timeout > 0 {
+		timeout = time.Duration(s.option.Health.Timeout) * time.Second
+	}
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				done <- fmt.Errorf("panic: %v", e)
+			}
+		}()
+		done <- check.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timeout after %s", timeout)
+	}
+	result := HealthResult{Status: healthUp, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = healthDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ShuttingDown reports whether Shutdown has been called
+func (s *Server) ShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// healthRoutes registers the hidden health endpoints configured by `health`
+func (s *Server) healthRoutes() {
+	if s.option == nil || !s.option.Health.Enable {
+		return
+	}
+	o := s.option.Health
+	routes := map[string]HandlerFunc{
+		o.LivePath: func(c *Context) {
+			c.JSON(fasthttp.StatusOK, &HealthStatus{Status: healthUp})
+		},
+		o.Path: func(c *Context) {
+			s.writeHealth(c, s.Health(c.Context()))
+		},
+		o.ReadyPath: func(c *Context) {
+			if s.ShuttingDown() {
+				s.writeHealth(c, &HealthStatus{Status: healthShuttingDown})
+				return
+			}
+			s.writeHealth(c, s.Health(c.Context()))
+		},
+	}
+	paths := make([]string, 0, len(routes))
+	for p := range routes {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		s.addRoute("@Health", "GET", p, routes[p])
+	}
+}
+
+func (s *Server) writeHealth(c *Context, status *HealthStatus) {
+	code := fasthttp.StatusOK
+	if status.Status != healthUp {
+		code = fasthttp.StatusServiceUnavailable
+	}
+	c.ctx.Response.Header.Set("Cache-Control", "no-store")
+	c.JSON(code, status)
+}
+
+// OnShutdown registers hooks which will be called by Shutdown after the server stops serving,
+// e.g. closing database connections or flushing spans
+func (s *Server) OnShutdown(hooks ...func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, hooks...)
+}
+
+// Shutdown stops the server gracefully: readiness fails first and the server keeps serving for
+// `health.drainDelay` (if `health.enable`) so that load balancers see the failing readiness and drain it
+// (it should be longer than their probe interval), then open connections are waited for
+// and the hooks registered by OnShutdown are called in reverse order
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.shuttingDown.CompareAndSwap(false, true) {
+		return nil
+	}
+	// nobody watches readiness if health endpoints are disabled, so there is nothing to drain
+	if s.option != nil && s.option.Health.Enable && s.option.Health.DrainDelay > 0 {
+		select {
+		case <-time.After(time.Duration(s.option.Health.DrainDelay) * time.Second):
+		case <-ctx.Done():
+		}
+	}
+	var errs []error
+	if s.server != nil {
+		if err := s.server.ShutdownWithContext(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := len(s.shutdownHooks) - 1; i >= 0; i-- {
+		if err := s.shutdownHooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,148 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+type testHealthService struct {
+	err error
+}
+
+func (t *testHealthService) Init(provider IProvider) {}
+
+func (t *testHealthService) HealthChecks() []HealthCheck {
+	return []HealthCheck{{Name: "db", Check: func(ctx context.Context) error { return t.err }}}
+}
+
+func TestHealth(t *testing.T) {
+	s := newTestServer()
+	s.option.Health = HealthOption{Enable: true, Path: "/healthz", ReadyPath: "/readyz", LivePath: "/livez", Timeout: 1}
+	db := &testHealthService{}
+	s.UseService(db)
+	s.AddHealthCheck("cache", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 10*time.Millisecond)
+	s.initGlobal()
+	if len(s.Routes()) != 0 {
+		t.Errorf("health routes should be hidden, Routes() = %v", s.Routes())
+	}
+
+	get := func(uri string) (int, string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("http://localhost" + uri)
+		s.Handler()(ctx)
+		return ctx.Response.StatusCode(), string(ctx.Response.Body())
+	}
+
+	tests := []struct {
+		name     string
+		uri      string
+		dbErr    error
+		shutdown bool
+		want     int
+		contains []string
+	}{
+		{name: "live", uri: "/livez", want: 200, contains: []string{`"status":"up"`}},
+		{name: "timeout", uri: "/healthz", want: 503, contains: []string{`"cache":{"status":"down","error":"timeout after 10ms"`, `"db":{"status":"up"`}},
+		{name: "db down", uri: "/readyz", dbErr: errors.New("connection refused"), want: 503, contains: []string{`"error":"connection refused"`}},
+		{name: "shutting down", uri: "/readyz", shutdown: true, want: 503, contains: []string{`"status":"shutting down"`}},
+		{name: "live during shutdown", uri: "/livez", shutdown: true, want: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.err = tt.dbErr
+			if tt.shutdown {
+				if err := s.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			code, body := get(tt.uri)
+			if code != tt.want {
+				t.Errorf("status = %d, want %d, body = %s", code, tt.want, body)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(body, want) {
+					t.Errorf("body = %s, should contain %s", body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestShutdownHooks(t *testing.T) {
+	s := newTestServer()
+	var order []string
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	}, func(ctx context.Context) error {
+		order = append(order, "second")
+		return errors.New("flush failed")
+	})
+	if err := s.Shutdown(context.Background()); err == nil || err.Error() != "flush failed" {
+		t.Errorf("Shutdown() = %v", err)
+	}
+	if strings.Join(order, ",") != "second,first" {
+		t.Errorf("hooks order = %v", order)
+	}
+	if !s.ShuttingDown() || s.Shutdown(context.Background()) != nil {
+		t.Error("Shutdown should only run once")
+	}
+}
+
+func TestShutdown_drainDelay(t *testing.T) {
+	s := newTestServer()
+	s.option.Health = HealthOption{Enable: true, ReadyPath: "/readyz", DrainDelay: 1}
+	hooked := make(chan struct{})
+	s.OnShutdown(func(ctx context.Context) error {
+		close(hooked)
+		return nil
+	})
+	s.initGlobal()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Shutdown(context.Background())
+	}()
+	for !s.ShuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("http://localhost/readyz")
+	s.Handler()(ctx)
+	if ctx.Response.StatusCode() != 503 {
+		t.Errorf("readyz = %d during drain, want 503", ctx.Response.StatusCode())
+	}
+	select {
+	case <-hooked:
+		t.Error("hooks should be called after drain delay")
+	default:
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	<-hooked
+}
+
+func TestHealth_disabled(t *testing.T) {
+	s := newTestServer()
+	s.option.Health = HealthOption{DrainDelay: 5}
+	s.initGlobal()
+	start := time.Now()
+	if err := s.Shutdown(context.Background()); err != nil || time.Since(start) > time.Second {
+		t.Errorf("Shutdown() = %v after %s, should not drain when health is disabled", err, time.Since(start))
+	}
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("http://localhost/healthz")
+	s.Handler()(ctx)
+	if ctx.Response.StatusCode() != 404 {
+		t.Errorf("healthz = %d, want 404 when health is not enabled", ctx.Response.StatusCode())
+	}
+}
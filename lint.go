@@ -0,0 +1,329 @@
+package fw
+
+import (
+	"fmt"
+	"github.com/linxlib/astp"
+	"github.com/linxlib/astp/constants"
+	types2 "github.com/linxlib/astp/types"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is a problem found in the annotations of a controller
+type Diagnostic struct {
+	Pos     string // file:line of the controller or method, empty when the source is not available
+	Target  string // Controller or Controller.Method
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos == "" {
+		return fmt.Sprintf("%s: %s", d.Target, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Target, d.Message)
+}
+
+// bodyAttrs are the param attributes which bind the request body
+var bodyAttrs = []constants.AttrType{
+	constants.AT_BODY, constants.AT_JSON, constants.AT_XML,
+	constants.AT_FORM, constants.AT_MULTIPART, constants.AT_PLAIN,
+}
+
+// pathParam matches the name of path params, so /users/{id} and /users/{name} are treated as the same route
+var pathParam = regexp.MustCompile(`\{[^}]*}`)
+
+// linter checks annotations of controllers
+type linter struct {
+	basePath string
+	// isMiddleware reports whether attr is a middleware in slot, unknown attributes are not checked when it is nil
+	isMiddleware func(attr string, slot SlotType) bool
+	positions    map[string]string
+	checkRoutes  bool              // duplicate routes are reported by Server.addRoute at runtime
+	routes       map[string]string // METHOD path -> Controller.Method
+	diagnostics  []Diagnostic
+}
+
+func newLinter(basePath string, positions map[string]string, isMiddleware func(attr string, slot SlotType) bool) *linter {
+	if basePath == "" {
+		basePath = "/"
+	}
+	return &linter{
+		basePath:     basePath,
+		isMiddleware: isMiddleware,
+		positions:    positions,
+		checkRoutes:  true,
+		routes:       make(map[string]string),
+	}
+}
+
+func (l *linter) report(target string, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Pos:     l.positions[target],
+		Target:  target,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// lintController is the part of a controller checked by the linter, see newLintController
+type lintController struct {
+	Name    string
+	Route   string            // value of @Route
+	Attrs   []*types2.Comment // custom attributes (middlewares)
+	Ignore  []string          // values of @Ignore
+	Methods []lintMethod
+}
+
+type lintMethod struct {
+	Name       string
+	Methods    []string // http methods, see methodRoutes
+	Paths      []string
+	Attrs      []*types2.Comment // custom attributes (middlewares)
+	Ignore     []string          // values of @Ignore
+	BodyParams []string          // names of params bound from request body
+}
+
+// newLintController collects what the linter checks from astp metadata of ctl
+func newLintController(ctl *types2.Struct) lintController {
+	c := lintController{
+		Name:   ctl.Name,
+		Route:  ctl.GetAttrValue(constants.AT_ROUTE),
+		Attrs:  ctl.GetCustomAttrs(),
+		Ignore: ignoreValues(ctl.GetAttrs()),
+	}
+	ctl.VisitMethods(func(element *types2.Function) bool {
+		return !element.Private && element.HasAttrs()
+	}, func(method *types2.Function) {
+		m := lintMethod{
+			Name:   method.Name,
+			Attrs:  method.GetCustomAttrs(),
+			Ignore: ignoreValues(method.GetAttrs()),
+		}
+		m.Methods, m.Paths = methodRoutes(method)
+		for _, param := range method.Param {
+			if param.Struct != nil && hasAnyAttr(param.Struct, bodyAttrs) {
+				m.BodyParams = append(m.BodyParams, param.Struct.Name)
+			}
+		}
+		c.Methods = append(c.Methods, m)
+	})
+	return c
+}
+
+// ignoreValues returns the values of @Ignore in attrs
+func ignoreValues(attrs []*types2.Comment) []string {
+	values := make([]string, 0)
+	for _, attr := range attrs {
+		if attr.AttrType == constants.AT_IGNORE {
+			values = append(values, attr.AttrValue)
+		}
+	}
+	return values
+}
+
+// checkController checks the controller and its methods, it returns the diagnostics found in ctl
+func (l *linter) checkController(ctl *types2.Struct) []Diagnostic {
+	return l.check(newLintController(ctl))
+}
+
+func (l *linter) check(ctl lintController) []Diagnostic {
+	n := len(l.diagnostics)
+	base := l.basePath
+	if ctl.Route != "" {
+		base = joinRoute(base, ctl.Route)
+	}
+	ctlAttrs := make(map[string]bool)
+	for _, attr := range ctl.Attrs {
+		ctlAttrs[strings.ToUpper(attr.CustomAttr)] = true
+		if l.isUnknown(attr.CustomAttr, SlotController) {
+			l.report(ctl.Name, "unknown middleware @%s", attr.CustomAttr)
+		}
+	}
+	for _, value := range ctl.Ignore {
+		for _, name := range ignoreNames(value) {
+			if name != "*" && !l.isGlobal(name) {
+				l.report(ctl.Name, "@Ignore %s: only global middlewares can be ignored by a controller", name)
+			}
+		}
+	}
+	for _, method := range ctl.Methods {
+		target := ctl.Name + "." + method.Name
+
+		for _, m := range method.Methods {
+			if m != "GET" && m != "HEAD" {
+				continue
+			}
+			for _, param := range method.BodyParams {
+				l.report(target, "@%s can not have body param %s", m, param)
+			}
+		}
+
+		for _, attr := range method.Attrs {
+			if l.isUnknown(attr.CustomAttr, SlotMethod) {
+				l.report(target, "unknown middleware @%s", attr.CustomAttr)
+			}
+		}
+
+		for _, value := range method.Ignore {
+			names := ignoreNames(value)
+			if len(names) == 0 {
+				l.report(target, "@Ignore should name a middleware of %s or a global middleware", ctl.Name)
+			}
+			for _, name := range names {
+				if name != "*" && !ctlAttrs[strings.ToUpper(name)] && !l.isGlobal(name) {
+					l.report(target, "@Ignore %s: %s does not use this middleware", name, ctl.Name)
+				}
+			}
+		}
+
+		if !l.checkRoutes {
+			continue
+		}
+		for i, m := range method.Methods {
+			route := joinRoute(base, method.Paths[i])
+			key := m + " " + pathParam.ReplaceAllString(route, "{}")
+			if exist, ok := l.routes[key]; ok {
+				l.report(target, "duplicate route %s %s, already declared by %s", m, route, exist)
+				continue
+			}
+			l.routes[key] = target
+		}
+	}
+	return l.diagnostics[n:]
+}
+
+// isUnknown reports whether attr is not a known middleware
+func (l *linter) isUnknown(attr string, slot SlotType) bool {
+	if l.isMiddleware == nil {
+		return false
+	}
+	return isMiddlewareAttr(attr) && !l.isMiddleware(attr, slot)
+}
+
+// isGlobal reports whether name may be a global middleware, it is always true when middlewares are unknown
+func (l *linter) isGlobal(name string) bool {
+	return l.isMiddleware == nil || l.isMiddleware(name, SlotGlobal)
+}
+
+func hasAnyAttr(s *types2.Struct, attrs []constants.AttrType) bool {
+	for _, attr := range attrs {
+		if s.HasAttr(attr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint reads the astp metadata file and the source in dir, then reports problems of controller annotations:
+// body params on @GET/@HEAD methods, unknown middlewares, duplicate routes and @Ignore naming a middleware
+// which is neither used by the controller nor global.
+// middlewares are the attributes of registered middlewares, unknown middlewares are not checked when it is empty.
+func Lint(astFile string, dir string, basePath string, middlewares []string) ([]Diagnostic, error) {
+	if _, err := os.Stat(astFile); err != nil {
+		return nil, err
+	}
+	p := &astp.Parser{Project: &types2.Project{}}
+	p.Read(astFile)
+
+	var isMiddleware func(string, SlotType) bool
+	if len(middlewares) > 0 {
+		known := make(map[string]bool)
+		for _, m := range middlewares {
+			known[strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(m), "@"))] = true
+		}
+		isMiddleware = func(attr string, slot SlotType) bool {
+			return known[strings.ToUpper(attr)]
+		}
+	}
+	l := newLinter(basePath, sourcePositions(dir), isMiddleware)
+
+	names := make([]string, 0, len(p.FileMap))
+	for name := range p.FileMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, ctl := range p.FileMap[name].Structs {
+			if isController(ctl) {
+				l.checkController(ctl)
+			}
+		}
+	}
+	return l.diagnostics, nil
+}
+
+// sourcePositions returns file:line of structs and methods declared in dir (recursively),
+// keys are Struct and Struct.Method
+func sourcePositions(dir string) map[string]string {
+	positions := make(map[string]string)
+	fset := token.NewFileSet()
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		add := func(key string, pos token.Pos) {
+			if _, ok := positions[key]; !ok {
+				p := fset.Position(pos)
+				positions[key] = fmt.Sprintf("%s:%d", filepath.ToSlash(p.Filename), p.Line)
+			}
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						add(ts.Name.Name, ts.Pos())
+					}
+				}
+			case *ast.FuncDecl:
+				if recv := receiverName(decl); recv != "" {
+					add(recv+"."+decl.Name.Name, decl.Pos())
+				}
+			}
+		}
+		return nil
+	})
+	return positions
+}
+
+// receiverName returns the type name of the receiver of fn
+func receiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	typ := fn.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
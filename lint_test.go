@@ -0,0 +1,175 @@
+package fw
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/linxlib/astp/constants"
+	types2 "github.com/linxlib/astp/types"
+)
+
+func Test_sourcePositions(t *testing.T) {
+	dir := t.TempDir()
+	src := `package controllers
+
+type UserController struct{}
+
+func (u *UserController) List() {}
+
+func (u UserController) Get() {}
+
+func helper() {}
+`
+	if err := os.MkdirAll(filepath.Join(dir, "controllers"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "controllers", "user.go")
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := sourcePositions(dir)
+	want := map[string]int{
+		"UserController":      3,
+		"UserController.List": 5,
+		"UserController.Get":  7,
+	}
+	for key, line := range want {
+		if pos := got[key]; pos != fmt.Sprintf("%s:%d", filepath.ToSlash(file), line) {
+			t.Errorf("position of %s = %v, want line %d", key, pos, line)
+		}
+	}
+	if _, ok := got["helper"]; ok {
+		t.Errorf("functions should not be included")
+	}
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{Pos: "controllers/user.go:5", Target: "UserController.List", Message: "unknown middleware @Auth"}
+	if got := d.String(); got != "controllers/user.go:5: UserController.List: unknown middleware @Auth" {
+		t.Errorf("String() = %v", got)
+	}
+	d.Pos = ""
+	if got := d.String(); got != "UserController.List: unknown middleware @Auth" {
+		t.Errorf("String() = %v", got)
+	}
+}
+
+func Test_linter_check(t *testing.T) {
+	// Auth is a controller and method middleware, Cors is a global middleware
+	isMiddleware := func(attr string, slot SlotType) bool {
+		switch slot {
+		case SlotGlobal:
+			return attr == "Cors"
+		default:
+			return attr == "Auth"
+		}
+	}
+	custom := func(names ...string) []*types2.Comment {
+		attrs := make([]*types2.Comment, 0, len(names))
+		for _, name := range names {
+			attrs = append(attrs, &types2.Comment{CustomAttr: name})
+		}
+		return attrs
+	}
+	tests := []struct {
+		name        string
+		controllers []lintController
+		checkRoutes bool
+		want        []string
+	}{
+		{
+			name: "ok",
+			controllers: []lintController{{Name: "UserController", Route: "/users", Attrs: custom("Auth"), Ignore: []string{"Cors"},
+				Methods: []lintMethod{
+					{Name: "List", Methods: []string{"GET"}, Paths: []string{"/"}, Ignore: []string{"Auth"}},
+					{Name: "Create", Methods: []string{"POST"}, Paths: []string{"/"}, BodyParams: []string{"CreateUser"}},
+				}}},
+			checkRoutes: true,
+		},
+		{
+			name: "body param on get",
+			controllers: []lintController{{Name: "UserController", Methods: []lintMethod{
+				{Name: "List", Methods: []string{"GET", "HEAD"}, Paths: []string{"/", "/"}, BodyParams: []string{"ListUser"}},
+			}}},
+			want: []string{
+				"UserController.List: @GET can not have body param ListUser",
+				"UserController.List: @HEAD can not have body param ListUser",
+			},
+		},
+		{
+			name: "unknown middleware",
+			controllers: []lintController{{Name: "UserController", Attrs: custom("Cache"), Methods: []lintMethod{
+				{Name: "List", Methods: []string{"GET"}, Paths: []string{"/"}, Attrs: custom("Auth", "Timing")},
+			}}},
+			want: []string{
+				"UserController: unknown middleware @Cache",
+				"UserController.List: unknown middleware @Timing",
+			},
+		},
+		{
+			name: "duplicate route",
+			controllers: []lintController{
+				{Name: "UserController", Route: "/users", Methods: []lintMethod{
+					{Name: "Get", Methods: []string{"GET"}, Paths: []string{"/{id}"}},
+				}},
+				{Name: "AccountController", Methods: []lintMethod{
+					{Name: "Get", Methods: []string{"GET", "POST"}, Paths: []string{"/users/{name}", "/users/{name}"}},
+				}},
+			},
+			checkRoutes: true,
+			want:        []string{"AccountController.Get: duplicate route GET /users/{name}, already declared by UserController.Get"},
+		},
+		{
+			name: "duplicate route reported by server",
+			controllers: []lintController{
+				{Name: "UserController", Methods: []lintMethod{{Name: "Get", Methods: []string{"GET"}, Paths: []string{"/users"}}}},
+				{Name: "AccountController", Methods: []lintMethod{{Name: "Get", Methods: []string{"GET"}, Paths: []string{"/users"}}}},
+			},
+		},
+		{
+			name: "malformed ignore",
+			controllers: []lintController{{Name: "UserController", Attrs: custom("Auth"), Ignore: []string{"Auth", "*"},
+				Methods: []lintMethod{
+					{Name: "List", Methods: []string{"GET"}, Paths: []string{"/"}, Ignore: []string{"", "Auth, Cors", "Cache"}},
+				}}},
+			want: []string{
+				"UserController: @Ignore Auth: only global middlewares can be ignored by a controller",
+				"UserController.List: @Ignore should name a middleware of UserController or a global middleware",
+				"UserController.List: @Ignore Cache: UserController does not use this middleware",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLinter("/", nil, isMiddleware)
+			l.checkRoutes = tt.checkRoutes
+			got := make([]string, 0)
+			for _, ctl := range tt.controllers {
+				for _, d := range l.check(ctl) {
+					got = append(got, d.String())
+				}
+			}
+			if tt.want == nil {
+				tt.want = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ignoreValues(t *testing.T) {
+	attrs := []*types2.Comment{
+		{AttrType: constants.AT_GET, AttrValue: "/users"},
+		{AttrType: constants.AT_IGNORE, AttrValue: "Auth"},
+		{CustomAttr: "Cache"},
+		{AttrType: constants.AT_IGNORE, AttrValue: "Cors,Log"},
+	}
+	if got := ignoreValues(attrs); !reflect.DeepEqual(got, []string{"Auth", "Cors,Log"}) {
+		t.Errorf("ignoreValues() = %v", got)
+	}
+}
@@ -104,6 +104,15 @@ func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
 		b.WriteString(" [" + captionStr + "] ")
 	}
 
+	// request_id is the only field written by Formatter, other fields are left to hooks
+	if id, ok := entry.Data["request_id"]; ok {
+		if f.Colorize {
+			color.Fprintf(b, "[%s] ", gray(id))
+		} else {
+			b.WriteString(fmt.Sprintf("[%v] ", id))
+		}
+	}
+
 	var data string
 	if f.PrettyPrint {
 		data = marshalIndent(root.Fields)
@@ -0,0 +1,143 @@
+package fw
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// MetricsOption configures the metrics middleware, it lives under `metrics`
+type MetricsOption struct {
+	Path      string    `yaml:"path" default:"/metrics"` //route of prometheus metrics, base path is not prepended
+	Namespace string    `yaml:"namespace" default:"fw"`  //prefix of metric names
+	Buckets   []float64 `yaml:"buckets"`                 //buckets of latency histogram in seconds, prometheus.DefBuckets if empty
+}
+
+// Metrics holds the prometheus registry of server, it is mapped into the injector by the metrics middleware
+// so that services can register custom metrics:
+//
+//	func (s *OrderService) Init(provider fw.IProvider) {
+//		var m *fw.Metrics
+//		_ = provider.Provide(&m)
+//		s.orders = m.NewCounter("orders_total", "created orders", "channel")
+//	}
+type Metrics struct {
+	Registry  *prometheus.Registry
+	namespace string
+
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inflight *prometheus.GaugeVec
+}
+
+// NewCounter registers a counter named namespace_name
+func (m *Metrics) NewCounter(name string, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: m.namespace, Name: name, Help: help}, labels)
+	m.Registry.MustRegister(c)
+	return c
+}
+
+// NewGauge registers a gauge named namespace_name
+func (m *Metrics) NewGauge(name string, help string, labels ...string) *prometheus.GaugeVec {
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: m.namespace, Name: name, Help: help}, labels)
+	m.Registry.MustRegister(g)
+	return g
+}
+
+// NewHistogram registers a histogram named namespace_name, prometheus.DefBuckets will be used if buckets is nil
+func (m *Metrics) NewHistogram(name string, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: m.namespace, Name: name, Help: help, Buckets: buckets}, labels)
+	m.Registry.MustRegister(h)
+	return h
+}
+
+// newMetrics creates the registry with go runtime, process and http metrics
+func newMetrics(option MetricsOption) *Metrics {
+	if option.Namespace == "" {
+		option.Namespace = "fw"
+	}
+	m := &Metrics{
+		Registry:  prometheus.NewRegistry(),
+		namespace: option.Namespace,
+	}
+	m.Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	m.requests = m.NewCounter("http_requests_total", "number of http requests", "route", "controller", "handler", "method", "code")
+	m.latency = m.NewHistogram("http_request_duration_seconds", "latency of http requests", option.Buckets, "route", "controller", "handler", "method")
+	m.inflight = m.NewGauge("http_requests_in_flight", "number of http requests being served", "route", "controller", "handler")
+	return m
+}
+
+// NewMetricsMiddleware returns a global middleware which records requests, latency and in-flight requests
+// labeled by route pattern, controller and method name, and serves them with go runtime stats
+// in prometheus text format on `metrics.path` (/metrics by default), which is hidden from the route table.
+// Metrics will be mapped into the injector, so use it before UseService to register custom metrics.
+func NewMetricsMiddleware() IMiddlewareGlobal {
+	return &MetricsMiddleware{
+		MiddlewareGlobal: NewMiddlewareGlobal("Metrics"),
+	}
+}
+
+type MetricsMiddleware struct {
+	*MiddlewareGlobal
+	option  MetricsOption
+	metrics *Metrics
+}
+
+func (m *MetricsMiddleware) DoInitOnce() {
+	m.option = MetricsOption{Path: "/metrics"}
+	if s, ok := m.provider.(*Server); ok {
+		if s.option != nil {
+			m.option = s.option.Metrics
+		}
+		m.metrics = newMetrics(m.option)
+		s.Map(m.metrics)
+		s.MapTo(m.metrics.Registry, (*prometheus.Registerer)(nil))
+		return
+	}
+	m.metrics = newMetrics(m.option)
+}
+
+// Priority makes metrics measure the other middlewares
+func (m *MetricsMiddleware) Priority() int {
+	return -700
+}
+
+func (m *MetricsMiddleware) Router(ctx *MiddlewareContext) []*RouteItem {
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(m.metrics.Registry, promhttp.HandlerOpts{}))
+	path := m.option.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	return []*RouteItem{{
+		Method:           "GET",
+		Path:             path,
+		IsHide:           true,
+		H:                func(c *Context) { handler(c.ctx) },
+		Middleware:       m,
+		OverrideBasePath: true,
+	}}
+}
+
+func (m *MetricsMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	if ctx.Ignored {
+		return ctx.Next
+	}
+	controller, handler := ctx.Controller, ctx.Handler
+	return func(c *Context) {
+		route := c.RoutePattern()
+		method := string(c.ctx.Method())
+		inflight := m.metrics.inflight.WithLabelValues(route, controller, handler)
+		inflight.Inc()
+		start := time.Now()
+		defer func() {
+			inflight.Dec()
+			m.metrics.latency.WithLabelValues(route, controller, handler, method).Observe(time.Since(start).Seconds())
+			m.metrics.requests.WithLabelValues(route, controller, handler, method, strconv.Itoa(c.ctx.Response.StatusCode())).Inc()
+		}()
+		ctx.Next(c)
+	}
+}
@@ -0,0 +1,48 @@
+package fw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	s := newTestServer()
+	s.Use(NewMetricsMiddleware())
+	var reg prometheus.Registerer
+	if err := s.Provide(&reg); err != nil || reg == nil {
+		t.Fatalf("Provide(Registerer) = %v", err)
+	}
+	s.Invoke(func(m *Metrics) {
+		m.NewCounter("orders_total", "created orders", "channel").WithLabelValues("web").Inc()
+	})
+	Handle(s, "GET", "/users/{id}", func(c *Context, req *struct{}) (*testUser, error) {
+		return &testUser{}, nil
+	})
+	if len(s.Routes()) != 1 {
+		t.Errorf("metrics route should be hidden, Routes() = %v", s.Routes())
+	}
+
+	for _, uri := range []string{"/users/1", "/users/2", "/metrics"} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("http://localhost" + uri)
+		s.Handler()(ctx)
+		if uri != "/metrics" {
+			continue
+		}
+		body := string(ctx.Response.Body())
+		for _, want := range []string{
+			`fw_http_requests_total{code="200",controller="Handle",handler="",method="GET",route="/users/{id}"} 2`,
+			`fw_http_request_duration_seconds_count{controller="Handle",handler="",method="GET",route="/users/{id}"} 2`,
+			`fw_http_requests_in_flight{controller="Handle",handler="",route="/users/{id}"} 0`,
+			`fw_orders_total{channel="web"} 1`,
+			`go_goroutines`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("metrics should contain %s", want)
+			}
+		}
+	}
+}
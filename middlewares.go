@@ -1,16 +1,22 @@
 package fw
 
-import "strings"
+import (
+	"slices"
+	"sort"
+	"strings"
+)
 
 // MiddlewareContainer stores middlewares
 // global middlewares will be stored with its Name as key
 type MiddlewareContainer struct {
-	ms map[SlotType]map[string]IMiddleware
+	ms    map[SlotType]map[string]IMiddleware
+	order map[SlotType][]string // keys of ms in registration order
 }
 
 func NewMiddlewareContainer() *MiddlewareContainer {
 	m := &MiddlewareContainer{
-		ms: make(map[SlotType]map[string]IMiddleware),
+		ms:    make(map[SlotType]map[string]IMiddleware),
+		order: make(map[SlotType][]string),
 	}
 	m.ms[SlotGlobal] = make(map[string]IMiddleware)
 	m.ms[SlotController] = make(map[AttributeName]IMiddleware)
@@ -18,25 +24,30 @@ func NewMiddlewareContainer() *MiddlewareContainer {
 	return m
 }
 
-// Reg will store middleware to specified map according to its slot
+// Reg will store middleware to specified map according to its slot.
+// a middleware registered again with the same key replaces the old one and keeps its position
 func (m *MiddlewareContainer) Reg(middleware IMiddleware) {
 	middleware.doReg()
 	st := middleware.GetSlot()
+	var key string
 	switch st {
 	case SlotGlobal:
-		m.ms[st][middleware.Name()] = middleware
-	case SlotController:
-		m.ms[st][strings.ToUpper(middleware.Attribute())] = middleware
-	case SlotMethod:
-		m.ms[st][strings.ToUpper(middleware.Attribute())] = middleware
+		key = middleware.Name()
+	case SlotController, SlotMethod:
+		key = strings.ToUpper(middleware.Attribute())
+	default:
+		return
 	}
-
+	if _, ok := m.ms[st][key]; !ok {
+		m.order[st] = append(m.order[st], key)
+	}
+	m.ms[st][key] = middleware
 }
 
-// VisitAll visit all middlewares with the specified slot
+// VisitAll visit all middlewares with the specified slot by priority and registration order
 // if f returns true, the loop will terminate
 func (m *MiddlewareContainer) VisitAll(slot string, f func(middleware IMiddleware) bool) bool {
-	for _, middleware := range m.ms[slot] {
+	for _, middleware := range m.sorted(slot) {
 		if f(middleware) == true {
 			return true
 		}
@@ -44,6 +55,16 @@ func (m *MiddlewareContainer) VisitAll(slot string, f func(middleware IMiddlewar
 	return false
 }
 
+// sorted returns middlewares of slot sorted by priority and registration order
+func (m *MiddlewareContainer) sorted(slot string) []IMiddleware {
+	result := make([]IMiddleware, 0, len(m.order[slot]))
+	for _, key := range m.order[slot] {
+		result = append(result, m.ms[slot][key])
+	}
+	sortMiddlewares(result)
+	return result
+}
+
 // GetByAttribute returns middleware with specified slot and attr.
 func (m *MiddlewareContainer) GetByAttribute(slot string, attribute string) (IMiddleware, bool) {
 	if mid, ok := m.ms[slot][attribute]; ok {
@@ -53,10 +74,10 @@ func (m *MiddlewareContainer) GetByAttribute(slot string, attribute string) (IMi
 	}
 }
 
-// GetGlobal iterate global middlewares
+// GetGlobal iterate global middlewares by priority and registration order
 // stop at `f` returns true
 func (m *MiddlewareContainer) GetGlobal(f func(middleware IMiddlewareGlobal) bool) bool {
-	for _, middleware := range m.ms[SlotGlobal] {
+	for _, middleware := range m.sorted(SlotGlobal) {
 		if f(middleware.(IMiddlewareGlobal)) == true {
 			return true
 		}
@@ -81,3 +102,27 @@ func (m *MiddlewareContainer) GetByAttributeMethod(attribute string) (IMiddlewar
 		return nil, false
 	}
 }
+
+// priority returns the priority of middleware, 0 if it does not implement IPriority
+func priority(middleware any) int {
+	if p, ok := middleware.(IPriority); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// annotationOrder sorts middlewares annotated on a controller or method (in annotation order) into the order they run:
+// by priority, and the last annotated one first for equal priorities. mid returns the middleware of an element
+func annotationOrder[T any](mids []T, mid func(T) any) {
+	slices.Reverse(mids)
+	sort.SliceStable(mids, func(i, j int) bool {
+		return priority(mid(mids[i])) < priority(mid(mids[j]))
+	})
+}
+
+// sortMiddlewares sorts middlewares by priority, the original order is kept for equal priorities
+func sortMiddlewares[T IMiddlewareBase](middlewares []T) {
+	sort.SliceStable(middlewares, func(i, j int) bool {
+		return priority(middlewares[i]) < priority(middlewares[j])
+	})
+}
@@ -0,0 +1,222 @@
+package fw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linxlib/astp/constants"
+	types2 "github.com/linxlib/astp/types"
+	"github.com/valyala/fasthttp"
+)
+
+type orderMiddleware struct {
+	*MiddlewareGlobal
+	priority int
+	trace    *[]string
+}
+
+func (o *orderMiddleware) Priority() int {
+	return o.priority
+}
+
+func (o *orderMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	return func(c *Context) {
+		*o.trace = append(*o.trace, o.Name())
+		ctx.Next(c)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		priorities []int
+		want       string
+	}{
+		{name: "registration order", priorities: []int{0, 0, 0, 0}, want: "A > B > C > D"},
+		{name: "priority", priorities: []int{10, 0, -10, 0}, want: "C > B > D > A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			trace := make([]string, 0)
+			for i, name := range []string{"A", "B", "C", "D"} {
+				s.Use(&orderMiddleware{MiddlewareGlobal: NewMiddlewareGlobal(name), priority: tt.priorities[i], trace: &trace})
+			}
+			Handle(s, "GET", "/", func(c *Context, req *struct{}) (*testUser, error) {
+				return &testUser{}, nil
+			})
+			if got := strings.Join(s.Routes()[0].Middlewares, " > "); got != tt.want {
+				t.Errorf("chain = %s, want %s", got, tt.want)
+			}
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost/")
+			s.Handler()(ctx)
+			if got := strings.Join(trace, " > "); got != tt.want {
+				t.Errorf("trace = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type routeMiddleware struct {
+	*MiddlewareGlobal
+	got *MiddlewareContext
+}
+
+func (r *routeMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	r.got = ctx
+	return ctx.Next
+}
+
+func TestWithGlobals_route(t *testing.T) {
+	s := newTestServer()
+	m := &routeMiddleware{MiddlewareGlobal: NewMiddlewareGlobal("Route")}
+	s.midGlobals = append(s.midGlobals, m)
+	s.withGlobals("UserController", "Get", func(c *Context) {})
+	// ControllerName of global middlewares keeps its old value, the route is in Controller and Handler
+	if m.got.ControllerName != "Route" || m.got.MethodName != "" {
+		t.Errorf("ControllerName = %q, MethodName = %q", m.got.ControllerName, m.got.MethodName)
+	}
+	if m.got.Controller != "UserController" || m.got.Handler != "Get" {
+		t.Errorf("Controller = %q, Handler = %q", m.got.Controller, m.got.Handler)
+	}
+}
+
+func Test_annotationOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		priorities []int // of @A @B @C @D in annotation order
+		want       string
+	}{
+		// the last annotated middleware is the outermost, as before priorities were introduced
+		{name: "annotation only", priorities: []int{0, 0, 0, 0}, want: "D > C > B > A"},
+		{name: "priority", priorities: []int{-10, 0, 0, 10}, want: "A > C > B > D"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mids := make([]IMiddlewareBase, 0)
+			for i, name := range []string{"A", "B", "C", "D"} {
+				if tt.priorities[i] == 0 {
+					// without IPriority
+					mids = append(mids, NewMiddlewareMethod(name, name))
+					continue
+				}
+				mids = append(mids, &priorityMethodMiddleware{MiddlewareMethod: NewMiddlewareMethod(name, name), priority: tt.priorities[i]})
+			}
+			annotationOrder(mids, func(m IMiddlewareBase) any { return m })
+			names := make([]string, 0, len(mids))
+			for _, mid := range mids {
+				names = append(names, mid.Attribute())
+			}
+			if got := strings.Join(names, " > "); got != tt.want {
+				t.Errorf("order = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type priorityMethodMiddleware struct {
+	*MiddlewareMethod
+	priority int
+}
+
+func (p *priorityMethodMiddleware) Priority() int {
+	return p.priority
+}
+
+type skipMiddleware struct {
+	*MiddlewareGlobal
+	trace *[]string
+}
+
+func (m *skipMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	if ctx.Ignored {
+		return ctx.Next
+	}
+	return func(c *Context) {
+		*m.trace = append(*m.trace, m.Name())
+		ctx.Next(c)
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	tests := []struct {
+		name      string
+		ignore    []ignoreSet
+		wantChain string
+		wantTrace string
+	}{
+		{name: "none", wantChain: "Log > Auth", wantTrace: "Log > Auth"},
+		{name: "one", ignore: []ignoreSet{newIgnoreSet("auth")}, wantChain: "Log > !Auth", wantTrace: "Log"},
+		{name: "list", ignore: []ignoreSet{newIgnoreSet("Auth, @Log")}, wantChain: "!Log > !Auth"},
+		{name: "controller and method", ignore: []ignoreSet{newIgnoreSet("Log"), newIgnoreSet("Auth")}, wantChain: "!Log > !Auth"},
+		{name: "wildcard", ignore: []ignoreSet{newIgnoreSet("*")}, wantChain: "!Log > !Auth"},
+		{name: "controller lines", ignore: []ignoreSet{parseIgnore([]*types2.Comment{
+			{AttrType: constants.AT_IGNORE, AttrValue: "Log"},
+			{AttrType: constants.AT_GET, AttrValue: "/users"},
+			{AttrType: constants.AT_IGNORE, AttrValue: "Auth"},
+		})}, wantChain: "!Log > !Auth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			trace := make([]string, 0)
+			s.Use(&skipMiddleware{NewMiddlewareGlobal("Log"), &trace}, &skipMiddleware{NewMiddlewareGlobal("Auth"), &trace})
+			s.initGlobal()
+			if got := strings.Join(s.globalChain(tt.ignore...), " > "); got != tt.wantChain {
+				t.Errorf("chain = %s, want %s", got, tt.wantChain)
+			}
+			s.withGlobals("", "", func(c *Context) {}, tt.ignore...)(newContext(&fasthttp.RequestCtx{}))
+			if got := strings.Join(trace, " > "); got != tt.wantTrace {
+				t.Errorf("trace = %s, want %s", got, tt.wantTrace)
+			}
+		})
+	}
+}
+
+type rateLimitParams struct {
+	Rate  int `validate:"required|min:1"`
+	Burst int `form:"burst,default=1"`
+}
+
+type rateLimitMiddleware struct {
+	*MiddlewareMethod
+}
+
+func (r *rateLimitMiddleware) Params() any {
+	return new(rateLimitParams)
+}
+
+func TestMiddlewareParams(t *testing.T) {
+	mid := &rateLimitMiddleware{NewMiddlewareMethod("RateLimit", "RateLimit")}
+	tests := []struct {
+		param   string
+		want    rateLimitParams
+		wantErr string
+	}{
+		{param: "rate=10&burst=20", want: rateLimitParams{Rate: 10, Burst: 20}},
+		{param: "Rate=10", want: rateLimitParams{Rate: 10, Burst: 1}},
+		{param: "rate=abc", wantErr: "invalid syntax"},
+		{param: "rate=10&size=1", wantErr: `unknown param "size"`},
+		{param: "burst=2", wantErr: "Rate"},
+		{param: "rate=%zz", wantErr: "malformed params"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			ctx := newMiddlewareContext("UserController", "List", SlotMethod, tt.param, nil)
+			err := ctx.decodeParams(mid)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("decodeParams() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeParams() error = %v", err)
+			}
+			if got := GetParams[rateLimitParams](ctx); got == nil || *got != tt.want {
+				t.Errorf("GetParams() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package fw
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// exitPanic is the value raised by Context.Exit to stop a handler, it is not an error
+const exitPanic = "fw"
+
+// PanicHook is called after a panic has been recovered and logged, err is the recovered value
+// and stack is the formatted goroutine stack. it can be used for alerting.
+// the response has not been written yet when hooks are called.
+type PanicHook func(c *Context, err any, stack []byte)
+
+// OnPanic registers hooks which will be called on every recovered panic
+func (s *Server) OnPanic(hooks ...PanicHook) {
+	s.panicHooks = append(s.panicHooks, hooks...)
+}
+
+// recover handles panics raised in controller methods (including Context.Exit)
+func (s *Server) recover(c *Context) {
+	if err := recover(); err != nil {
+		if err == exitPanic {
+			return
+		}
+		s.handlePanic(c, err)
+	}
+}
+
+// panicHandler is the last resort for panics escaping from middlewares
+func (s *Server) panicHandler(ctx *fasthttp.RequestCtx, err any) {
+	if err == exitPanic {
+		return
+	}
+	s.handlePanic(newContext(ctx, s), err)
+}
+
+// handlePanic logs err with request information, calls the panic hooks and writes a 500 response.
+// a debug page with stack and source will be written in Dev mode if the client accepts html
+func (s *Server) handlePanic(c *Context, err any) {
+	stack := stackFrames(4)
+	raw := []byte(formatFrames(stack))
+	if s.logger != nil {
+		// method and path are in the message since Formatter only writes request_id of fields
+		s.logger.WithContext(c.ctx).Errorf("panic recovered: %s %s: %v\n%s",
+			c.ctx.Method(), c.ctx.Path(), err, raw)
+	}
+	for _, hook := range s.panicHooks {
+		s.callPanicHook(hook, c, err, raw)
+	}
+
+	c.ctx.Response.ResetBody()
+	c.ctx.Response.SkipBody = false
+	if s.option != nil && s.option.Dev && strings.Contains(c.GetHeader("Accept"), "text/html") {
+		var buf bytes.Buffer
+		if e := debugPage.Execute(&buf, debugData{
+			Error:  fmt.Sprint(err),
+			Method: string(c.ctx.Method()),
+			Path:   string(c.ctx.Path()),
+			Frames: stack,
+		}); e == nil {
+			c.Data(fasthttp.StatusInternalServerError, "text/html; charset=utf-8", buf.Bytes())
+			return
+		}
+	}
+	msg := fasthttp.StatusMessage(fasthttp.StatusInternalServerError)
+	if s.option != nil && s.option.Dev {
+		msg = fmt.Sprint(err)
+	}
+	c.JSON(fasthttp.StatusInternalServerError, H{"error": msg})
+}
+
+// callPanicHook calls hook and ignores panics raised by itself
+func (s *Server) callPanicHook(hook PanicHook, c *Context, err any, stack []byte) {
+	defer func() {
+		if e := recover(); e != nil && s.logger != nil {
+			s.logger.Errorf("panic hook: %v", e)
+		}
+	}()
+	hook(c, err, stack)
+}
+
+// NewRecoveryMiddleware returns a global middleware which recovers panics raised by the whole chain,
+// including other global middlewares. hooks will be registered as OnPanic does.
+//
+//	s.Use(fw.NewRecoveryMiddleware(func(c *fw.Context, err any, stack []byte) {
+//		alert(err)
+//	}))
+func NewRecoveryMiddleware(hooks ...PanicHook) IMiddlewareGlobal {
+	return &RecoveryMiddleware{
+		MiddlewareGlobal: NewMiddlewareGlobal("Recovery"),
+		hooks:            hooks,
+	}
+}
+
+type RecoveryMiddleware struct {
+	*MiddlewareGlobal
+	hooks  []PanicHook
+	server *Server
+}
+
+func (r *RecoveryMiddleware) DoInitOnce() {
+	if s, ok := r.provider.(*Server); ok {
+		r.server = s
+		s.OnPanic(r.hooks...)
+	}
+}
+
+// Priority makes recovery the outermost middleware
+func (r *RecoveryMiddleware) Priority() int {
+	return -1000
+}
+
+func (r *RecoveryMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	if ctx.Ignored {
+		return ctx.Next
+	}
+	return func(c *Context) {
+		if r.server != nil {
+			defer r.server.recover(c)
+		}
+		ctx.Next(c)
+	}
+}
+
+// Frame is a stack frame shown in the debug page
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// SourceLine is a line of source code around a Frame
+type SourceLine struct {
+	Number  int
+	Code    string
+	Current bool
+}
+
+type debugData struct {
+	Error  string
+	Method string
+	Path   string
+	Frames []Frame
+}
+
+// stackFrames returns the frames of current goroutine, runtime frames are skipped
+func stackFrames(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	result := make([]Frame, 0, n)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			result = append(result, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return result
+}
+
+func formatFrames(frames []Frame) string {
+	var sb strings.Builder
+	for _, frame := range frames {
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(fmt.Sprintf("%s:%d\n", frame.File, frame.Line))
+	}
+	return sb.String()
+}
+
+// source returns lines around line of file, it returns nil if the file is not readable
+func source(file string, line int, around int) []SourceLine {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	start := max(line-around, 1)
+	end := min(line+around, len(lines))
+	result := make([]SourceLine, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		result = append(result, SourceLine{Number: i, Code: lines[i-1], Current: i == line})
+	}
+	return result
+}
+
+var debugPage = template.Must(template.New("panic").Funcs(template.FuncMap{
+	"source": func(f Frame) []SourceLine { return source(f.File, f.Line, 5) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>500 {{.Error}}</title>
+<style>
+body{font-family:sans-serif;margin:2em;color:#222}
+h1{color:#c0392b;font-size:1.4em}
+.frame{margin:1em 0;border:1px solid #ddd}
+.fn{background:#f5f5f5;padding:.4em .6em;font-weight:bold}
+.file{color:#666;font-weight:normal}
+pre{margin:0;padding:.4em 0;overflow-x:auto}
+.line{display:block;padding:0 .6em}
+.cur{background:#fdecea}
+.no{color:#999;display:inline-block;width:4em}
+</style>
+</head>
+<body>
+<h1>panic: {{.Error}}</h1>
+<p>{{.Method}} {{.Path}}</p>
+{{range .Frames}}<div class="frame">
+<div class="fn">{{.Function}} <span class="file">{{.File}}:{{.Line}}</span></div>
+{{with source .}}<pre>{{range .}}<span class="line{{if .Current}} cur{{end}}"><span class="no">{{.Number}}</span>{{.Code}}</span>{{end}}</pre>{{end}}
+</div>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,106 @@
+package fw
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRecover(t *testing.T) {
+	s := newTestServer()
+	buf := &bytes.Buffer{}
+	s.logger = logrus.New()
+	s.logger.SetOutput(buf)
+	s.logger.SetFormatter(File())
+	var hooked any
+	s.OnPanic(func(c *Context, err any, stack []byte) {
+		hooked = err
+		if len(stack) == 0 {
+			t.Error("empty stack")
+		}
+	})
+	s.OnPanic(func(c *Context, err any, stack []byte) {
+		panic("hook should not break the response")
+	})
+	Handle(s, "GET", "/panic", func(c *Context, req *struct{}) (*testUser, error) {
+		panic(errors.New("boom"))
+	})
+	Handle(s, "GET", "/exit", func(c *Context, req *struct{}) (*testUser, error) {
+		c.ErrorExitWithCode(403, errors.New("forbidden"))
+		return nil, nil
+	})
+
+	tests := []struct {
+		name       string
+		uri        string
+		dev        bool
+		accept     string
+		wantStatus int
+		wantBody   string
+		wantHook   bool
+	}{
+		{name: "json", uri: "/panic", wantStatus: 500, wantBody: `{"error":"Internal Server Error"}`, wantHook: true},
+		{name: "dev json", uri: "/panic", dev: true, wantStatus: 500, wantBody: `{"error":"boom"}`, wantHook: true},
+		{name: "dev html", uri: "/panic", dev: true, accept: "text/html", wantStatus: 500, wantBody: "<h1>panic: boom</h1>", wantHook: true},
+		{name: "exit", uri: "/exit", wantStatus: 403, wantBody: `{"error":"forbidden"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooked = nil
+			buf.Reset()
+			s.option.Dev = tt.dev
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost" + tt.uri)
+			ctx.Request.Header.Set("Accept", tt.accept)
+			s.Handler()(ctx)
+			if ctx.Response.StatusCode() != tt.wantStatus {
+				t.Errorf("status = %d, want %d", ctx.Response.StatusCode(), tt.wantStatus)
+			}
+			if body := string(ctx.Response.Body()); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %s, want %s", body, tt.wantBody)
+			}
+			if (hooked != nil) != tt.wantHook {
+				t.Errorf("hooked = %v, want %v", hooked, tt.wantHook)
+			}
+			// the log should tell which request failed
+			if tt.wantHook && !strings.Contains(buf.String(), "panic recovered: GET "+tt.uri+": boom") {
+				t.Errorf("log = %q, want method and path", buf.String())
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	s := newTestServer()
+	var hooked bool
+	s.Use(NewRecoveryMiddleware(func(c *Context, err any, stack []byte) {
+		hooked = true
+	}))
+	s.initGlobal()
+	// the recovery middleware wraps the panicking one
+	s.midGlobals = append([]IMiddlewareCtl{&panicMiddleware{NewMiddlewareGlobal("Panic")}}, s.midGlobals...)
+	Handle(s, "GET", "/ok", func(c *Context, req *struct{}) (*testUser, error) {
+		return &testUser{}, nil
+	})
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("http://localhost/ok")
+	s.Handler()(ctx)
+	if ctx.Response.StatusCode() != 500 || !hooked {
+		t.Errorf("status = %d, hooked = %v", ctx.Response.StatusCode(), hooked)
+	}
+}
+
+type panicMiddleware struct {
+	*MiddlewareGlobal
+}
+
+func (p *panicMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	return func(c *Context) {
+		panic("middleware")
+	}
+}
@@ -0,0 +1,210 @@
+package fw
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderXRequestID is the header which carries the request id
+const HeaderXRequestID = "X-Request-ID"
+
+// maxRequestIDLength limits the request id accepted from clients
+const maxRequestIDLength = 128
+
+var logrusType = reflect.TypeOf((*logrus.Logger)(nil))
+
+// requestIDKey is the key of request id in user values of fasthttp.RequestCtx and in context.Context
+type requestIDKey struct{}
+
+// RequestID returns the id of current request set by the request id middleware,
+// or X-Request-ID of response and request if it is not used.
+func (c *Context) RequestID() string {
+	if id := RequestIDFromContext(c.ctx); id != "" {
+		return id
+	}
+	if id := c.ctx.Response.Header.Peek(HeaderXRequestID); len(id) > 0 {
+		return string(id)
+	}
+	return string(c.ctx.Request.Header.Peek(HeaderXRequestID))
+}
+
+// SetRequestID stores id as the request id and echoes it in response
+func (c *Context) SetRequestID(id string) {
+	c.ctx.SetUserValue(requestIDKey{}, id)
+	c.ctx.Response.Header.Set(HeaderXRequestID, id)
+}
+
+// Context returns a context.Context carrying the request id and the span of tracing middleware,
+// use it for outbound calls made with NewHTTPClient and child spans
+//
+//	req, _ := http.NewRequestWithContext(c.Context(), "GET", url, nil)
+//	resp, err := client.Do(req)
+func (c *Context) Context() context.Context {
+	ctx := context.Background()
+	if span := c.span(); span.SpanContext().IsValid() {
+		ctx = trace.ContextWithSpan(ctx, span)
+	}
+	return ContextWithRequestID(ctx, c.RequestID())
+}
+
+// Logger returns an entry of server logger for current request, the request id will be added to its lines
+func (c *Context) Logger() *logrus.Entry {
+	logger := c.serverLogger()
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return logger.WithContext(c.ctx)
+}
+
+// serverLogger returns the *logrus.Logger injected into c, nil if there is none
+func (c *Context) serverLogger() *logrus.Logger {
+	if v := c.inj.Get(logrusType); v.IsValid() {
+		logger, _ := v.Interface().(*logrus.Logger)
+		return logger
+	}
+	return nil
+}
+
+// ContextWithRequestID returns a copy of ctx carrying request id
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id carried by ctx, fasthttp.RequestCtx is supported as well
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestIDMiddleware returns a global middleware which accepts X-Request-ID from clients,
+// or generates one by UUID if it is missing or invalid. the id is stored in Context (see Context.RequestID)
+// and echoed in response. during the request it is added as `request_id` to lines logged by:
+//   - Context.Logger and the *logrus.Entry injected into controllers and middlewares
+//   - the *logrus.Logger injected into controllers and middlewares, which is a copy of server logger for the request
+//   - server logger called with WithContext(c.Context()), e.g. the panic log of Recovery
+//
+// lines logged by server logger without a context, or by loggers created elsewhere, do not carry it.
+func NewRequestIDMiddleware() IMiddlewareGlobal {
+	return &RequestIDMiddleware{
+		MiddlewareGlobal: NewMiddlewareGlobal("RequestID"),
+		Generator:        UUID,
+	}
+}
+
+type RequestIDMiddleware struct {
+	*MiddlewareGlobal
+	Generator func() string // generates request id, UUID by default
+}
+
+// Priority makes request id available to other middlewares, only Recovery runs before it
+func (r *RequestIDMiddleware) Priority() int {
+	return -900
+}
+
+func (r *RequestIDMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	if ctx.Ignored {
+		return ctx.Next
+	}
+	return func(c *Context) {
+		id := string(c.ctx.Request.Header.Peek(HeaderXRequestID))
+		if !validRequestID(id) {
+			id = r.Generator()
+		}
+		c.SetRequestID(id)
+		if logger := c.serverLogger(); logger != nil {
+			c.Map(requestLogger(logger, id))
+		}
+		c.Map(c.Logger())
+		ctx.Next(c)
+	}
+}
+
+// validRequestID reports whether id from client can be used, it should be printable ascii and not too long
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDHook adds request_id to log entries created with a context carrying it, see Context.Logger.
+// id is used for entries without it, see requestLogger
+type requestIDHook struct {
+	id string
+}
+
+func (requestIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h requestIDHook) Fire(entry *logrus.Entry) error {
+	id := RequestIDFromContext(entry.Context)
+	if id == "" {
+		id = h.id
+	}
+	if id != "" {
+		entry.Data["request_id"] = id
+	}
+	return nil
+}
+
+// requestLogger returns a copy of logger which adds id to all its lines.
+// the copy shares output, formatter and hooks with logger, but not its lock,
+// so writers which are not safe for concurrent use should be wrapped by a hook like LogFileHook
+func requestLogger(logger *logrus.Logger, id string) *logrus.Logger {
+	hooks := make(logrus.LevelHooks, len(logger.Hooks))
+	for level, hs := range logger.Hooks {
+		hooks[level] = append([]logrus.Hook(nil), hs...)
+	}
+	hooks.Add(requestIDHook{id: id})
+	return &logrus.Logger{
+		Out:          logger.Out,
+		Hooks:        hooks,
+		Formatter:    logger.Formatter,
+		ReportCaller: logger.ReportCaller,
+		Level:        logger.GetLevel(),
+		ExitFunc:     logger.ExitFunc,
+		BufferPool:   logger.BufferPool,
+	}
+}
+
+// RequestIDTransport sets X-Request-ID of outbound requests from their context, see Context.Context
+type RequestIDTransport struct {
+	Base http.RoundTripper // http.DefaultTransport if nil
+}
+
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := RequestIDFromContext(req.Context()); id != "" && req.Header.Get(HeaderXRequestID) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(HeaderXRequestID, id)
+	}
+	return base.RoundTrip(req)
+}
+
+// NewHTTPClient returns a http client which propagates request id to outbound calls
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &RequestIDTransport{},
+	}
+}
@@ -0,0 +1,102 @@
+package fw
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	s := newTestServer()
+	logger := logrus.New()
+	buf := &bytes.Buffer{}
+	logger.SetOutput(buf)
+	logger.SetFormatter(File())
+	logger.AddHook(requestIDHook{})
+	s.Map(logger)
+	s.Use(NewRequestIDMiddleware())
+
+	var gotID string
+	Handle(s, "GET", "/", func(c *Context, req *struct{}) (*testUser, error) {
+		gotID = c.RequestID()
+		c.Logger().Info("entry")
+		// the injected *logrus.Logger carries the id without a context
+		c.serverLogger().Info("logger")
+		logger.WithContext(c.Context()).Info("server")
+		return &testUser{}, nil
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "accept", header: "abc-123", want: "abc-123"},
+		{name: "generate", header: ""},
+		{name: "invalid", header: "a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost/")
+			if tt.header != "" {
+				ctx.Request.Header.Set(HeaderXRequestID, tt.header)
+			}
+			s.Handler()(ctx)
+			echoed := string(ctx.Response.Header.Peek(HeaderXRequestID))
+			if echoed == "" || echoed != gotID || (tt.want != "" && echoed != tt.want) {
+				t.Errorf("echoed = %q, RequestID() = %q, want %q", echoed, gotID, tt.want)
+			}
+			if tt.want == "" && len(echoed) != 36 {
+				t.Errorf("generated id = %q", echoed)
+			}
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("log = %q, want 3 lines", buf.String())
+			}
+			for _, line := range lines {
+				if !strings.Contains(line, "["+echoed+"]") {
+					t.Errorf("line = %q, want request id %s", line, echoed)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_fields(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{"request_id": "abc", "method": "GET"})
+	entry.Message = "hello"
+	b, err := File().Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := string(b); !strings.Contains(line, "[abc] hello") || strings.Contains(line, "method") {
+		t.Errorf("line = %q, want only request_id of fields", line)
+	}
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(HeaderXRequestID)
+	}))
+	defer ts.Close()
+
+	c := newContext(&fasthttp.RequestCtx{})
+	c.SetRequestID("abc-123")
+	req, _ := http.NewRequestWithContext(c.Context(), "GET", ts.URL, nil)
+	resp, err := NewHTTPClient(0).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if got != "abc-123" {
+		t.Errorf("X-Request-ID = %q, want abc-123", got)
+	}
+}
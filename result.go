@@ -0,0 +1,193 @@
+package fw
+
+import (
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"reflect"
+)
+
+// IResult is implemented by values which know how to write themselves into response.
+// a controller method can return an IResult instead of touching *Context, e.g.
+//
+//	func (h *HelloController) Index() (fw.IResult, error) {
+//		return fw.View{Name: "index.html", Data: fw.H{"title": "hello"}}, nil
+//	}
+type IResult interface {
+	Render(c *Context)
+}
+
+var (
+	_ IResult = View{}
+	_ IResult = Result{}
+	_ IResult = redirectResult{}
+	_ IResult = fileResult("")
+	_ IResult = statusResult(0)
+	_ IResult = codeResult{}
+)
+
+// View renders the html template with Name and Data.
+// templates should be loaded by Server.LoadHTMLGlob/LoadHTMLFiles/SetHTMLTemplate first
+type View struct {
+	Name string
+	Data any
+}
+
+func (v View) Render(c *Context) {
+	c.HTML(http.StatusOK, v.Name, v.Data)
+}
+
+// Result is a general result with status code, headers and body.
+//
+// Body will be written according to its type:
+// nil -> status message, []byte -> raw data, string -> text, others -> json
+type Result struct {
+	Status  int
+	Headers http.Header
+	Body    any
+}
+
+func (r Result) Render(c *Context) {
+	code := r.Status
+	if code == 0 {
+		code = http.StatusOK
+	}
+	c.setHeaders(r.Headers)
+	switch body := r.Body.(type) {
+	case nil:
+		c.SendStatus(code)
+	case []byte:
+		contentType := r.Headers.Get(fasthttp.HeaderContentType)
+		if contentType == "" {
+			contentType = MIMEOctetStream
+		}
+		c.Data(code, contentType, body)
+	case string:
+		c.String(code, body)
+	default:
+		c.JSON(code, body)
+	}
+}
+
+type redirectResult struct {
+	location string
+	code     int
+}
+
+func (r redirectResult) Render(c *Context) {
+	c.Redirect(r.code, r.location)
+}
+
+// RedirectTo returns a result which redirects to url with code (3xx)
+func RedirectTo(url string, code int) IResult {
+	return redirectResult{location: url, code: code}
+}
+
+type fileResult string
+
+func (f fileResult) Render(c *Context) {
+	c.File(string(f))
+}
+
+// SendFile returns a result which sends the file at path
+func SendFile(path string) IResult {
+	return fileResult(path)
+}
+
+type statusResult int
+
+func (s statusResult) Render(c *Context) {
+	c.SendStatus(int(s))
+}
+
+// Status returns a result which only sends the status code
+func Status(code int) IResult {
+	return statusResult(code)
+}
+
+const attrStatus = "STATUS"
+
+var (
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	headerType = reflect.TypeOf(http.Header{})
+)
+
+// checkResults checks the return values of a controller method.
+// supported signatures are:
+//
+//	func(...)
+//	func(...) error
+//	func(...) T
+//	func(...) (T, error)
+//	func(...) (T, int, error)              // int is the status code, it overrides the one of IResult unless it is 0
+//	func(...) (T, http.Header, error)      // headers will be added to response
+//	func(...) (T, int, http.Header, error)
+//
+// T can be an IResult (View, Result...) or any value which will be rendered as json.
+// the error is optional in all forms above.
+func checkResults(t reflect.Type) error {
+	n := t.NumOut()
+	if n > 0 && t.Out(n-1).Implements(errorType) {
+		n--
+	}
+	var hasStatus, hasHeader bool
+	for i := 1; i < n; i++ {
+		out := t.Out(i)
+		switch {
+		case out.Kind() == reflect.Int && !hasStatus:
+			hasStatus = true
+		case out == headerType && !hasHeader:
+			hasHeader = true
+		default:
+			return fmt.Errorf("unsupported return value #%d (%s) in %s", i, out, t)
+		}
+	}
+	return nil
+}
+
+// parseResults splits the return values of a controller method into body, status code, headers and error.
+// code will be used as status code when the method does not return one.
+func parseResults(values []reflect.Value, code int) (body reflect.Value, status int, header http.Header, err error) {
+	status = code
+	n := len(values)
+	if n > 0 && values[n-1].Type().Implements(errorType) {
+		if e, ok := values[n-1].Interface().(error); ok && e != nil {
+			err = e
+		}
+		n--
+	}
+	if n == 0 {
+		return
+	}
+	body = values[0]
+	explicit := false
+	for _, value := range values[1:n] {
+		switch {
+		case value.Kind() == reflect.Int:
+			if c := int(value.Int()); c != 0 {
+				status = c
+				explicit = true
+			}
+		case value.Type() == headerType:
+			header = value.Interface().(http.Header)
+		}
+	}
+	// the returned status code overrides the one written by IResult, e.g. (fw.View, 404, nil)
+	if explicit && !isNilValue(body) {
+		if r, ok := body.Interface().(IResult); ok {
+			body = reflect.ValueOf(codeResult{IResult: r, code: status})
+		}
+	}
+	return
+}
+
+// codeResult renders IResult with the status code returned along with it
+type codeResult struct {
+	IResult
+	code int
+}
+
+func (r codeResult) Render(c *Context) {
+	r.IResult.Render(c)
+	c.ctx.SetStatusCode(r.code)
+}
@@ -0,0 +1,240 @@
+package fw
+
+import (
+	"errors"
+	"fmt"
+	types2 "github.com/linxlib/astp/types"
+	"github.com/valyala/fasthttp"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResult_Render(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      IResult
+		wantStatus  int
+		wantBody    string
+		wantHeaders map[string]string
+	}{
+		{
+			name:       "status",
+			result:     Status(http.StatusNoContent),
+			wantStatus: http.StatusNoContent,
+			wantBody:   "",
+		},
+		{
+			name:       "result with json body",
+			result:     Result{Status: http.StatusCreated, Body: H{"id": 1}},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":1}`,
+		},
+		{
+			name: "result with headers and bytes",
+			result: Result{
+				Headers: http.Header{"Content-Type": {"text/csv"}, "X-Total": {"3"}},
+				Body:    []byte("a,b"),
+			},
+			wantStatus:  http.StatusOK,
+			wantBody:    "a,b",
+			wantHeaders: map[string]string{"Content-Type": "text/csv", "X-Total": "3"},
+		},
+		{
+			name:       "result without body",
+			result:     Result{Status: http.StatusAccepted},
+			wantStatus: http.StatusAccepted,
+			wantBody:   "Accepted",
+		},
+		{
+			name:        "redirect",
+			result:      RedirectTo("/login", http.StatusFound),
+			wantStatus:  http.StatusFound,
+			wantHeaders: map[string]string{"Location": "http://localhost/login"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost/")
+			c := newContext(ctx)
+			c.writeResult(http.StatusOK, tt.result)
+			if got := ctx.Response.StatusCode(); got != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := string(ctx.Response.Body()); got != tt.wantBody {
+					t.Errorf("body = %v, want %v", got, tt.wantBody)
+				}
+			}
+			for key, want := range tt.wantHeaders {
+				if got := string(ctx.Response.Header.Peek(key)); got != want {
+					t.Errorf("header %s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestView_Render(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<h1>{{.title}}</h1>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		load func(s *Server)
+	}{
+		{name: "glob", load: func(s *Server) { s.LoadHTMLGlob(filepath.Join(dir, "*.html")) }},
+		{name: "glob in dev", load: func(s *Server) {
+			s.option.Dev = true
+			s.LoadHTMLGlob(filepath.Join(dir, "*.html"))
+		}},
+		{name: "template", load: func(s *Server) {
+			s.SetHTMLTemplate(template.Must(template.New("index.html").Parse(`<h1>{{.title}}</h1>`)))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			tt.load(s)
+			ctx := &fasthttp.RequestCtx{}
+			c := newContext(ctx, s)
+			c.writeResult(http.StatusOK, View{Name: "index.html", Data: H{"title": "<hello>"}})
+			if got := ctx.Response.StatusCode(); got != http.StatusOK {
+				t.Errorf("status = %v, want %v", got, http.StatusOK)
+			}
+			if got := string(ctx.Response.Body()); got != "<h1>&lt;hello&gt;</h1>" {
+				t.Errorf("body = %v", got)
+			}
+			if got := string(ctx.Response.Header.ContentType()); got != "text/html; charset=utf-8" {
+				t.Errorf("content type = %v", got)
+			}
+		})
+	}
+}
+
+func Test_checkResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      any
+		wantErr bool
+	}{
+		{name: "none", fn: func() {}},
+		{name: "error", fn: func() error { return nil }},
+		{name: "body", fn: func() H { return nil }},
+		{name: "body and error", fn: func() (H, error) { return nil, nil }},
+		{name: "status", fn: func() (H, int, error) { return nil, 0, nil }},
+		{name: "header", fn: func() (H, http.Header, error) { return nil, nil, nil }},
+		{name: "status and header", fn: func() (H, int, http.Header, error) { return nil, 0, nil, nil }},
+		{name: "two status", fn: func() (H, int, int, error) { return nil, 0, 0, nil }, wantErr: true},
+		{name: "unknown", fn: func() (H, string, error) { return nil, "", nil }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkResults(reflect.TypeOf(tt.fn)); (err != nil) != tt.wantErr {
+				t.Errorf("checkResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseResults(t *testing.T) {
+	fn := func() (H, int, http.Header, error) {
+		return H{"id": 1}, http.StatusCreated, http.Header{"Location": {"/users/1"}}, nil
+	}
+	body, status, header, err := parseResults(reflect.ValueOf(fn).Call(nil), http.StatusOK)
+	if err != nil {
+		t.Fatalf("parseResults() error = %v", err)
+	}
+	if status != http.StatusCreated {
+		t.Errorf("status = %v, want %v", status, http.StatusCreated)
+	}
+	if header.Get("Location") != "/users/1" {
+		t.Errorf("header = %v", header)
+	}
+	if body.Interface().(H)["id"] != 1 {
+		t.Errorf("body = %v", body.Interface())
+	}
+
+	fn1 := func() (H, int, error) { return nil, 0, errors.New("failed") }
+	_, status, _, err = parseResults(reflect.ValueOf(fn1).Call(nil), http.StatusAccepted)
+	if err == nil || status != http.StatusAccepted {
+		t.Errorf("parseResults() status = %v, err = %v", status, err)
+	}
+}
+
+func TestContext_respond(t *testing.T) {
+	s := newTestServer()
+	s.SetHTMLTemplate(template.Must(template.New("index.html").Parse(`<h1>{{.title}}</h1>`)))
+	tests := []struct {
+		name       string
+		fn         any
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "view with code", fn: func() (View, int, error) {
+			return View{Name: "index.html", Data: H{"title": "missing"}}, http.StatusNotFound, nil
+		}, wantStatus: http.StatusNotFound, wantBody: "<h1>missing</h1>"},
+		{name: "view", fn: func() (View, error) {
+			return View{Name: "index.html", Data: H{"title": "hello"}}, nil
+		}, wantStatus: http.StatusOK, wantBody: "<h1>hello</h1>"},
+		{name: "result with code", fn: func() (IResult, int, error) {
+			return Result{Status: http.StatusCreated, Body: "x"}, http.StatusConflict, nil
+		}, wantStatus: http.StatusConflict, wantBody: "x"},
+		{name: "result with zero code", fn: func() (IResult, int, error) {
+			return Result{Status: http.StatusCreated, Body: "x"}, 0, nil
+		}, wantStatus: http.StatusCreated, wantBody: "x"},
+		{name: "json with code", fn: func() (H, int, error) {
+			return H{"id": 1}, http.StatusCreated, nil
+		}, wantStatus: http.StatusCreated, wantBody: `{"id":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			newContext(ctx, s).respond(parseResults(reflect.ValueOf(tt.fn).Call(nil), http.StatusOK))
+			if got := ctx.Response.StatusCode(); got != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if got := string(ctx.Response.Body()); got != tt.wantBody {
+				t.Errorf("body = %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func Test_statusCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		attrs     []*types2.Comment
+		want      int
+		wantPanic string
+	}{
+		{name: "default", want: http.StatusOK},
+		{name: "created", attrs: []*types2.Comment{{CustomAttr: "Status", AttrValue: "201"}}, want: http.StatusCreated},
+		{name: "case insensitive", attrs: []*types2.Comment{{CustomAttr: "STATUS", AttrValue: " 204 "}}, want: http.StatusNoContent},
+		{name: "not a number", attrs: []*types2.Comment{{CustomAttr: "Status", AttrValue: "Created"}},
+			wantPanic: "UserController.Create: @Status Created: should be a status code between 100 and 599"},
+		{name: "out of range", attrs: []*types2.Comment{{CustomAttr: "Status", AttrValue: "2010"}},
+			wantPanic: "UserController.Create: @Status 2010: should be a status code between 100 and 599"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err := recover()
+				if err == nil && tt.wantPanic == "" {
+					return
+				}
+				if fmt.Sprint(err) != tt.wantPanic {
+					t.Errorf("panic = %v, want %v", err, tt.wantPanic)
+				}
+			}()
+			if got := statusCode("UserController.Create", tt.attrs); got != tt.want {
+				t.Errorf("statusCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
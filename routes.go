@@ -0,0 +1,436 @@
+package fw
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/linxlib/astp"
+	"github.com/linxlib/astp/constants"
+	types2 "github.com/linxlib/astp/types"
+	"github.com/linxlib/fw/attribute"
+	"github.com/valyala/fasthttp"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route of the server
+type RouteInfo struct {
+	Controller  string   `json:"controller"`
+	Method      string   `json:"method"` // http method
+	Path        string   `json:"path"`
+	Handler     string   `json:"handler"`               // name of controller method
+	Middlewares []string `json:"middlewares,omitempty"` // middlewares in the order they run, e.g. Recovery > @Auth, ignored ones are prefixed with !
+}
+
+// WebDAVMethods are registered as http methods when `webdav` is enabled in config.
+// they are not by default, since attributes like @Lock or @Copy are likely to be middlewares
+var WebDAVMethods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+// RegisterHTTPMethod makes custom http methods (e.g. SEARCH) routable by @ attributes and Handle.
+// standard methods (including TRACE and CONNECT) are registered by default, see WebDAVMethods for WebDAV.
+// a name already registered as a middleware stays a middleware
+func RegisterHTTPMethod(methods ...string) {
+	for _, method := range methods {
+		attribute.RegAttributeType(method, attribute.TypeHttpMethod)
+	}
+}
+
+// configRouter applies AutoOptions and AutoHead to router
+func (s *Server) configRouter() {
+	s.router.HandleOPTIONS = s.option.AutoOptions
+	s.router.MethodNotAllowed = s.methodNotAllowed
+	s.router.PanicHandler = s.panicHandler
+	s.router.SaveMatchedRoutePath = true
+}
+
+// withGlobals wraps next (the handler of ctlName.methodName) with global middlewares, the first one runs first.
+// middlewares in ignore will be executed with MiddlewareContext.Ignored
+func (s *Server) withGlobals(ctlName, methodName string, next HandlerFunc, ignore ...ignoreSet) HandlerFunc {
+	for i := len(s.midGlobals) - 1; i >= 0; i-- {
+		global := s.midGlobals[i]
+		// ControllerName of global middlewares is their own name as before, the route is in Controller and Handler
+		ctx := newMiddlewareContext(global.Name(), "", SlotGlobal, "", next)
+		ctx.Controller, ctx.Handler = ctlName, methodName
+		ctx.Ignored = ignoreAny(ignore, global.Name())
+		next = global.Execute(ctx)
+	}
+	return next
+}
+
+// globalChain returns names of global middlewares in the order they run, ignored ones are prefixed with !
+func (s *Server) globalChain(ignore ...ignoreSet) []string {
+	chain := make([]string, 0, len(s.midGlobals))
+	for _, global := range s.midGlobals {
+		chain = append(chain, ignoredName(global.Name(), ignoreAny(ignore, global.Name())))
+	}
+	return chain
+}
+
+// ignoreSet holds the middlewares named by @Ignore, in upper case. "*" ignores all of them
+type ignoreSet map[string]bool
+
+// parseIgnore collects @Ignore attributes, e.g. `@Ignore Auth,Log` or `@Ignore *`. it can be declared more than once
+func parseIgnore(attrs []*types2.Comment) ignoreSet {
+	return newIgnoreSet(ignoreValues(attrs)...)
+}
+
+func newIgnoreSet(values ...string) ignoreSet {
+	set := make(ignoreSet)
+	for _, value := range values {
+		for _, name := range ignoreNames(value) {
+			set[strings.ToUpper(name)] = true
+		}
+	}
+	return set
+}
+
+// ignoreNames splits value of @Ignore into names of middlewares
+func ignoreNames(value string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		names = append(names, strings.TrimPrefix(name, "@"))
+	}
+	return names
+}
+
+// has reports whether middleware name (attribute or name of global middleware) is ignored
+func (i ignoreSet) has(name string) bool {
+	return i["*"] || i[strings.ToUpper(name)]
+}
+
+func ignoreAny(sets []ignoreSet, name string) bool {
+	for _, set := range sets {
+		if set.has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredName marks an ignored middleware in route table, e.g. !@Auth
+func ignoredName(name string, ignored bool) string {
+	if ignored {
+		return "!" + name
+	}
+	return name
+}
+
+// autoOptions answers OPTIONS for paths without an OPTIONS route, the Allow header has been set by router
+func (s *Server) autoOptions(c *Context) {
+	ctx := c.GetFastContext()
+	s.allowHead(ctx)
+	if ctx.Response.StatusCode() == fasthttp.StatusOK && len(ctx.Response.Body()) == 0 {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+}
+
+// allowHead adds HEAD to the Allow header set by router when GET is allowed and AutoHead is enabled
+func (s *Server) allowHead(ctx *fasthttp.RequestCtx) {
+	if !s.option.AutoHead {
+		return
+	}
+	methods := strings.Split(string(ctx.Response.Header.Peek("Allow")), ", ")
+	hasGet, hasHead := false, false
+	for _, m := range methods {
+		hasGet = hasGet || m == fasthttp.MethodGet
+		hasHead = hasHead || m == fasthttp.MethodHead
+	}
+	if hasGet && !hasHead {
+		methods = append(methods, fasthttp.MethodHead)
+		sort.Strings(methods)
+		ctx.Response.Header.Set("Allow", strings.Join(methods, ", "))
+	}
+}
+
+// methodNotAllowed responds 405 when the path matches routes of other methods,
+// the Allow header has been set by router.
+// HEAD will be served by the GET route without body when AutoHead is enabled
+func (s *Server) methodNotAllowed(ctx *fasthttp.RequestCtx) {
+	if s.option.AutoHead && ctx.IsHead() {
+		if h, _ := s.router.Lookup(fasthttp.MethodGet, string(ctx.Request.URI().PathOriginal()), ctx); h != nil {
+			ctx.Response.Header.Del("Allow")
+			ctx.Response.SkipBody = true
+			h(ctx)
+			return
+		}
+	}
+	s.allowHead(ctx)
+	c := newContext(ctx)
+	c.JSON(fasthttp.StatusMethodNotAllowed, H{"error": fasthttp.StatusMessage(fasthttp.StatusMethodNotAllowed)})
+}
+
+// isMiddlewareAttr reports whether the custom attribute can be a middleware,
+// attributes registered with other types (http methods, @Status, attributes of plugins...) can not
+func isMiddlewareAttr(name string) bool {
+	t, ok := attribute.GetAttributeType(name)
+	return !ok || t == attribute.TypeMiddleware
+}
+
+// isController reports whether the struct should be treated as a controller
+func isController(element *types2.Struct) bool {
+	return element.HasAttr(constants.AT_CONTROLLER) || strings.HasSuffix(element.Name, controllerAttr)
+}
+
+// methodRoutes returns http methods and paths declared by @GET/@POST... on method,
+// or the route derived from the method name (e.g. GetUserInfo -> GET /user_info) when none is declared
+func methodRoutes(method *types2.Function) (methods []string, paths []string) {
+	for _, attr := range method.GetAttrs() {
+		switch attr.AttrType {
+		case constants.AT_GET, constants.AT_POST, constants.AT_PUT, constants.AT_DELETE,
+			constants.AT_PATCH, constants.AT_OPTIONS, constants.AT_HEAD:
+			methods = append(methods, strings.ToUpper(constants.AttrNames[attr.AttrType]))
+			paths = append(paths, attr.AttrValue)
+		}
+	}
+	// TRACE, CONNECT and custom methods are not known by astp
+	for _, attr := range method.GetCustomAttrs() {
+		if attribute.IsHttpMethod(attr.CustomAttr) {
+			methods = append(methods, strings.ToUpper(attr.CustomAttr))
+			paths = append(paths, attr.AttrValue)
+		}
+	}
+	if len(methods) == 0 {
+		if m, p, ok := handleDefaultMethodRoute(method.Name, !method.Private); ok {
+			methods = append(methods, m)
+			paths = append(paths, p)
+		}
+	}
+	return
+}
+
+// ReadRoutes reads the astp metadata file and returns the routes of all controllers without starting a server.
+// since middlewares are not registered, the custom attributes on controllers and methods are listed as middlewares,
+// and routes provided by middlewares are not included.
+func ReadRoutes(astFile string, basePath string) ([]RouteInfo, error) {
+	if _, err := os.Stat(astFile); err != nil {
+		return nil, err
+	}
+	parser := &astp.Parser{Project: &types2.Project{}}
+	parser.Read(astFile)
+	if basePath == "" {
+		basePath = "/"
+	}
+
+	names := make([]string, 0, len(parser.FileMap))
+	for name := range parser.FileMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	routes := make([]RouteInfo, 0)
+	for _, name := range names {
+		for _, ctl := range parser.FileMap[name].Structs {
+			if !isController(ctl) {
+				continue
+			}
+			routes = append(routes, controllerRoutes(ctl, basePath)...)
+		}
+	}
+	return routes, nil
+}
+
+// controllerRoutes returns routes of ctl declared by attributes
+func controllerRoutes(ctl *types2.Struct, basePath string) []RouteInfo {
+	base := basePath
+	if r := ctl.GetAttrValue(constants.AT_ROUTE); r != "" {
+		base = joinRoute(base, r)
+	}
+	routes := make([]RouteInfo, 0)
+	ctl.VisitMethods(func(element *types2.Function) bool {
+		return !element.Private && element.HasAttrs()
+	}, func(method *types2.Function) {
+		ignore := parseIgnore(method.GetAttrs())
+		// controller middlewares run before method ones and the last annotated one runs first,
+		// global middlewares and priorities are only known at runtime
+		middlewares := make([]string, 0)
+		ctlAttrs := ctl.GetCustomAttrs()
+		for i := len(ctlAttrs) - 1; i >= 0; i-- {
+			if attr := ctlAttrs[i]; isMiddlewareAttr(attr.CustomAttr) {
+				middlewares = append(middlewares, ignoredName("@"+attr.CustomAttr, ignore.has(attr.CustomAttr)))
+			}
+		}
+		methodAttrs := method.GetCustomAttrs()
+		for i := len(methodAttrs) - 1; i >= 0; i-- {
+			if attr := methodAttrs[i]; isMiddlewareAttr(attr.CustomAttr) {
+				middlewares = append(middlewares, "@"+attr.CustomAttr)
+			}
+		}
+		methods, paths := methodRoutes(method)
+		for i, m := range methods {
+			routes = append(routes, RouteInfo{
+				Controller:  ctl.Name,
+				Method:      m,
+				Path:        joinRoute(base, paths[i]),
+				Handler:     method.Name,
+				Middlewares: middlewares,
+			})
+		}
+	})
+	return routes
+}
+
+// groupRoutes groups routes by controller, in the order of registration
+func groupRoutes(routes []RouteInfo) [][]RouteInfo {
+	groups := make([][]RouteInfo, 0)
+	index := make(map[string]int)
+	for _, route := range routes {
+		i, ok := index[route.Controller]
+		if !ok {
+			i = len(groups)
+			index[route.Controller] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], route)
+	}
+	return groups
+}
+
+// WriteRoutes writes routes into w in format table, json or markdown
+func WriteRoutes(w io.Writer, routes []RouteInfo, format string) error {
+	switch strings.ToLower(format) {
+	case "", "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "CONTROLLER\tMETHOD\tPATH\tHANDLER\tMIDDLEWARES")
+		for _, group := range groupRoutes(routes) {
+			for _, r := range group {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Controller, r.Method, r.Path, r.Handler, strings.Join(r.Middlewares, " > "))
+			}
+		}
+		return tw.Flush()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(routes)
+	case "markdown", "md":
+		_, _ = fmt.Fprintln(w, "| Controller | Method | Path | Handler | Middlewares |")
+		_, _ = fmt.Fprintln(w, "| --- | --- | --- | --- | --- |")
+		for _, group := range groupRoutes(routes) {
+			for _, r := range group {
+				_, _ = fmt.Fprintf(w, "| %s | %s | `%s` | %s | %s |\n", r.Controller, r.Method, r.Path, r.Handler, strings.Join(r.Middlewares, " > "))
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, should be table, json or markdown", format)
+	}
+}
+
+// RouteError is a problem found when registering a route
+type RouteError struct {
+	Owner  string // Controller.Method, or the middleware which provides the route
+	Method string
+	Path   string
+	Err    error
+}
+
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("%s: %s %s: %v", e.Owner, e.Method, e.Path, e.Err)
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// RouteErrors are all problems found when registering routes
+type RouteErrors []*RouteError
+
+func (e RouteErrors) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d route(s) can not be registered:", len(e)))
+	for _, err := range e {
+		sb.WriteString("\n  ")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// CheckRoutes returns the problems found when registering routes so far, nil if there is none.
+// in strict mode (StrictRoutes in config) the server will not start when there are problems
+func (s *Server) CheckRoutes() error {
+	if len(s.routeErrs) == 0 {
+		return nil
+	}
+	return s.routeErrs
+}
+
+// addRoute registers the route for owner and reports whether it is registered.
+// problems like unsupported methods, duplicate routes and conflicting params are collected (see CheckRoutes),
+// they are logged immediately unless in strict mode, which reports all of them before the server starts
+func (s *Server) addRoute(owner string, method string, path string, f HandlerFunc) bool {
+	method = strings.ToUpper(method)
+	err := s.routeConflict(method, path)
+	if err == nil {
+		err = s.safeRegisterRoute(method, path, f)
+	}
+	if err != nil {
+		routeErr := &RouteError{Owner: owner, Method: method, Path: path, Err: err}
+		s.routeErrs = append(s.routeErrs, routeErr)
+		if !s.option.StrictRoutes && s.logger != nil {
+			s.logger.Error(routeErr.Error())
+		}
+		return false
+	}
+	if route, exist, ok := s.routeShadow(method, path); ok && s.logger != nil {
+		s.logger.Warnf("%s: %s %s overlaps %s registered by %s", owner, method, path, route, exist)
+	}
+	if s.routeKeys == nil {
+		s.routeKeys = make(map[string]string)
+	}
+	s.routeKeys[method+" "+path] = owner
+	return true
+}
+
+// routeConflict checks the route against registered routes with the same method, WS is the same as ANY.
+// routes which differ only in names of params (e.g. /users/{id} and /users/{name}) are conflicting
+func (s *Server) routeConflict(method string, path string) error {
+	normalized := pathParam.ReplaceAllString(path, "{}")
+	for key, owner := range s.routeKeys {
+		m, p, _ := strings.Cut(key, " ")
+		if routerMethod(m) != routerMethod(method) {
+			continue
+		}
+		if p == path {
+			return fmt.Errorf("duplicate route, already registered by %s", owner)
+		}
+		if pathParam.ReplaceAllString(p, "{}") == normalized {
+			return fmt.Errorf("params conflict with %s %s registered by %s", m, p, owner)
+		}
+	}
+	return nil
+}
+
+// routeShadow returns the owner of an ANY route and a concrete method route on the same path,
+// the router allows this and the concrete one takes precedence, so it is only worth a warning
+func (s *Server) routeShadow(method string, path string) (string, string, bool) {
+	normalized := pathParam.ReplaceAllString(path, "{}")
+	isAny := routerMethod(method) == "ANY"
+	for key, owner := range s.routeKeys {
+		m, p, _ := strings.Cut(key, " ")
+		if (routerMethod(m) == "ANY") == isAny || pathParam.ReplaceAllString(p, "{}") != normalized {
+			continue
+		}
+		return m + " " + p, owner, true
+	}
+	return "", "", false
+}
+
+// routerMethod returns the method registered into router, WS routes are registered as ANY
+func routerMethod(method string) string {
+	if method == "WS" {
+		return "ANY"
+	}
+	return method
+}
+
+// safeRegisterRoute registers the route and converts the panic of router (e.g. conflicting wildcards) into error
+func (s *Server) safeRegisterRoute(method string, path string, f HandlerFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return s.registerRoute(method, path, f)
+}
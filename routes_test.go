@@ -0,0 +1,266 @@
+package fw
+
+import (
+	"bytes"
+	"errors"
+	"github.com/linxlib/fw/attribute"
+	"github.com/valyala/fasthttp"
+	"strings"
+	"testing"
+)
+
+func TestServer_Routes(t *testing.T) {
+	s := newTestServer()
+	Handle(s, "POST", "/orgs/{org}/users", func(c *Context, req *testCreateUser) (*testUser, error) {
+		return nil, nil
+	})
+	s.addRouteTable("UserController", "GET", "/users", "List", "@Auth", "@Log")
+	routes := s.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("Routes() = %v", routes)
+	}
+	if r := routes[0]; r.Controller != "Handle" || r.Method != "POST" || r.Path != "/orgs/{org}/users" {
+		t.Errorf("Routes()[0] = %+v", r)
+	}
+	if r := routes[1]; r.Handler != "List" || len(r.Middlewares) != 2 || r.Middlewares[0] != "@Auth" {
+		t.Errorf("Routes()[1] = %+v", r)
+	}
+}
+
+func TestWriteRoutes(t *testing.T) {
+	routes := []RouteInfo{
+		{Controller: "UserController", Method: "GET", Path: "/users", Handler: "List", Middlewares: []string{"@Auth"}},
+		{Controller: "HelloController", Method: "GET", Path: "/hello", Handler: "Hello"},
+		{Controller: "UserController", Method: "POST", Path: "/users", Handler: "Create"},
+	}
+	tests := []struct {
+		format  string
+		want    []string
+		wantErr bool
+	}{
+		{format: "table", want: []string{"CONTROLLER", "UserController   POST    /users  Create"}},
+		{format: "json", want: []string{`"middlewares": [`, `"handler": "Hello"`}},
+		{format: "markdown", want: []string{"| UserController | GET | `/users` | List | @Auth |"}},
+		{format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := WriteRoutes(buf, routes, tt.format); (err != nil) != tt.wantErr {
+				t.Fatalf("WriteRoutes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("WriteRoutes() = \n%s\nshould contain %q", buf.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func Test_handleDefaultMethodRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantMethod string
+		wantPath   string
+		wantOk     bool
+	}{
+		{name: "GetUserInfo", wantMethod: "GET", wantPath: "/user_info", wantOk: true},
+		{name: "PostUser", wantMethod: "POST", wantPath: "/user", wantOk: true},
+		{name: "Hello", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, path, ok := handleDefaultMethodRoute(tt.name, true)
+			if method != tt.wantMethod || path != tt.wantPath || ok != tt.wantOk {
+				t.Errorf("handleDefaultMethodRoute() = %v, %v, %v", method, path, ok)
+			}
+		})
+	}
+}
+
+func TestServer_addRoute(t *testing.T) {
+	s := newTestServer()
+	s.option.StrictRoutes = true
+	h := func(c *Context) {}
+	tests := []struct {
+		owner  string
+		method string
+		path   string
+		want   bool
+	}{
+		{owner: "UserController.Get", method: "GET", path: "/users/{id}", want: true},
+		{owner: "UserController.Update", method: "PUT", path: "/users/{id}", want: true},
+		{owner: "AdminController.Get", method: "GET", path: "/users/{id}", want: false},
+		{owner: "AdminController.Find", method: "GET", path: "/users/{name}", want: false},
+		{owner: "AdminController.Any", method: "ANY", path: "/users/{id}", want: true},
+		{owner: "AdminController.Ws", method: "WS", path: "/users/{id}", want: false},
+		{owner: "FileController.Trace", method: "FOO", path: "/files", want: false},
+		{owner: "FileController.Get", method: "GET", path: "/files/{path:*}", want: true},
+		{owner: "FileController.Info", method: "GET", path: "/static/{path:*}/info", want: false},
+	}
+	for _, tt := range tests {
+		if got := s.addRoute(tt.owner, tt.method, tt.path, h); got != tt.want {
+			t.Errorf("addRoute(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+	err := s.CheckRoutes()
+	var errs RouteErrors
+	if !errors.As(err, &errs) || len(errs) != 5 {
+		t.Fatalf("CheckRoutes() = %v", err)
+	}
+	if !strings.Contains(errs[0].Error(), "AdminController.Get: GET /users/{id}: duplicate route, already registered by UserController.Get") {
+		t.Errorf("errs[0] = %v", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "params conflict") {
+		t.Errorf("errs[1] = %v", errs[1])
+	}
+}
+
+func TestServer_addRoute_any(t *testing.T) {
+	s := newTestServer()
+	s.option.StrictRoutes = true
+	if !s.addRoute("FileController.Any", "ANY", "/files/{name}", func(c *Context) { c.String(200, "any") }) ||
+		!s.addRoute("FileController.Get", "GET", "/files/{id}", func(c *Context) { c.String(200, "get") }) {
+		t.Fatalf("ANY and GET on the same path should both be registered: %v", s.CheckRoutes())
+	}
+	for method, want := range map[string]string{"GET": "get", "POST": "any"} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI("http://localhost/files/1")
+		s.Handler()(ctx)
+		if got := string(ctx.Response.Body()); got != want {
+			t.Errorf("%s /files/1 = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestWebDAVMethods(t *testing.T) {
+	// WebDAV methods are opt-in, so user middlewares like @Lock are not routes
+	newTestServer().Use(NewMiddlewareCtl("Copy", "Copy"))
+	for _, name := range []string{"Lock", "Copy", "Move", "Unlock"} {
+		if attribute.IsHttpMethod(name) || !isMiddlewareAttr(name) {
+			t.Errorf("@%s should not be an http method by default", name)
+		}
+	}
+	RegisterHTTPMethod("copy")
+	if !isMiddlewareAttr("Copy") || attribute.IsHttpMethod("Copy") {
+		t.Errorf("@Copy registered as middleware should stay a middleware")
+	}
+}
+
+type testPropfind struct {
+	Path  string `path:"path"`
+	Depth string `header:"Depth"`
+}
+
+func TestHandle_methods(t *testing.T) {
+	s := newTestServer()
+	RegisterHTTPMethod("search", "propfind", "mkcol")
+	for _, method := range []string{"PROPFIND", "MKCOL", "TRACE", "SEARCH"} {
+		method := method
+		Handle(s, method, "/dav/{path}", func(c *Context, req *testPropfind) (*H, error) {
+			return &H{"method": method, "path": req.Path, "depth": req.Depth}, nil
+		})
+	}
+	if err := s.CheckRoutes(); err != nil {
+		t.Fatalf("CheckRoutes() = %v", err)
+	}
+
+	tests := []struct {
+		method     string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{method: "PROPFIND", wantStatus: 200, wantBody: `{"depth":"1","method":"PROPFIND","path":"a"}`},
+		{method: "SEARCH", wantStatus: 200, wantBody: `{"depth":"1","method":"SEARCH","path":"a"}`},
+		{method: "GET", wantStatus: 405, wantBody: `{"error":"Method Not Allowed"}`, wantAllow: "MKCOL, OPTIONS, PROPFIND, SEARCH, TRACE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.SetRequestURI("http://localhost/dav/a")
+			ctx.Request.Header.Set("Depth", "1")
+			s.Handler()(ctx)
+			if got := ctx.Response.StatusCode(); got != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if got := string(ctx.Response.Body()); got != tt.wantBody {
+				t.Errorf("body = %v, want %v", got, tt.wantBody)
+			}
+			if got := string(ctx.Response.Header.Peek("Allow")); got != tt.wantAllow {
+				t.Errorf("Allow = %v, want %v", got, tt.wantAllow)
+			}
+		})
+	}
+
+	s.addRoute("Test", "FOO", "/foo", func(c *Context) {})
+	if s.CheckRoutes() == nil {
+		t.Errorf("unknown method should not be registered")
+	}
+}
+
+type testOrg struct {
+	Org string `path:"org"`
+}
+
+func TestServer_autoOptionsAndHead(t *testing.T) {
+	s := newTestServer()
+	s.option.AutoOptions = true
+	s.option.AutoHead = true
+	s.configRouter()
+	Handle(s, "GET", "/users/{org}", func(c *Context, req *testOrg) (*testUser, error) {
+		return &testUser{Org: req.Org}, nil
+	})
+	Handle(s, "DELETE", "/users/{org}", func(c *Context, req *testOrg) (*testUser, error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		method     string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+		wantLength int
+	}{
+		{method: "OPTIONS", wantStatus: 204, wantAllow: "DELETE, GET, HEAD, OPTIONS"},
+		{method: "HEAD", wantStatus: 200, wantLength: len(`{"org":"fw","name":""}`)},
+		{method: "PUT", wantStatus: 405, wantBody: `{"error":"Method Not Allowed"}`, wantAllow: "DELETE, GET, HEAD, OPTIONS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.SetRequestURI("http://localhost/users/fw")
+			s.Handler()(ctx)
+			if got := ctx.Response.StatusCode(); got != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := string(ctx.Response.Body()); got != tt.wantBody {
+					t.Errorf("body = %v, want %v", got, tt.wantBody)
+				}
+			}
+			if got := string(ctx.Response.Header.Peek("Allow")); got != tt.wantAllow {
+				t.Errorf("Allow = %v, want %v", got, tt.wantAllow)
+			}
+			if tt.wantLength > 0 {
+				// the body is kept for Content-Length but will not be written
+				if !ctx.Response.SkipBody || len(ctx.Response.Body()) != tt.wantLength {
+					t.Errorf("HEAD should skip body, SkipBody = %v, body = %s", ctx.Response.SkipBody, ctx.Response.Body())
+				}
+			}
+		})
+	}
+
+	s.option.AutoHead = false
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("HEAD")
+	ctx.Request.SetRequestURI("http://localhost/users/fw")
+	s.Handler()(ctx)
+	if ctx.Response.StatusCode() != 405 {
+		t.Errorf("HEAD should not be served when AutoHead is disabled")
+	}
+}
@@ -0,0 +1,39 @@
+// Command doctor reports problems of controller annotations in astp metadata.
+// it is used by `fw doctor` in the project which depends on fw:
+//
+//	go run github.com/linxlib/fw/tools/doctor -f gen.json -middlewares Auth,Log
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/linxlib/fw"
+	"os"
+	"strings"
+)
+
+func main() {
+	file := flag.String("f", "gen.gz", "astp metadata file")
+	dir := flag.String("dir", ".", "source directory of project")
+	base := flag.String("base", "/", "base path of routes")
+	middlewares := flag.String("middlewares", "", "attributes of middlewares used by project, separated by comma. unknown middlewares are not checked when empty")
+	flag.Parse()
+
+	var known []string
+	if *middlewares != "" {
+		known = strings.Split(*middlewares, ",")
+	}
+	diagnostics, err := fw.Lint(*file, *dir, *base, known)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, d := range diagnostics {
+		fmt.Println(d.String())
+	}
+	if len(diagnostics) > 0 {
+		fmt.Printf("%d problem(s) found\n", len(diagnostics))
+		os.Exit(1)
+	}
+	fmt.Println("no problems found")
+}
@@ -0,0 +1,29 @@
+// Command routes prints the routes declared in astp metadata without starting the server.
+// it is used by `fw routes` in the project which depends on fw:
+//
+//	go run github.com/linxlib/fw/tools/routes -f gen.json -format markdown
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/linxlib/fw"
+	"os"
+)
+
+func main() {
+	file := flag.String("f", "gen.gz", "astp metadata file")
+	base := flag.String("base", "/", "base path of routes")
+	format := flag.String("format", "table", "output format: table, json or markdown")
+	flag.Parse()
+
+	routes, err := fw.ReadRoutes(*file, *base)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err = fw.WriteRoutes(os.Stdout, routes, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,20 @@
+// Package tracetest provides span exporters to test code traced by fw.NewTracingMiddleware
+//
+//	exporter := tracetest.NewInMemoryExporter()
+//	s.Use(fw.NewTracingMiddleware(exporter))
+//	...
+//	spans := exporter.GetSpans()
+package tracetest
+
+import (
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// InMemoryExporter keeps exported spans in memory
+type InMemoryExporter = tracetest.InMemoryExporter
+
+// NewInMemoryExporter returns an exporter which keeps spans in memory,
+// spans are exported when they end if it is given to fw.NewTracingMiddleware
+func NewInMemoryExporter() *InMemoryExporter {
+	return tracetest.NewInMemoryExporter()
+}
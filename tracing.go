@@ -0,0 +1,246 @@
+package fw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/linxlib/fw"
+
+// TracingOption configures the tracing middleware, it lives under `tracing`
+type TracingOption struct {
+	ServiceName string  `yaml:"serviceName"`                       //name of server by default
+	Exporter    string  `yaml:"exporter" default:"none"`           //otlp or none, spans are not exported by default
+	Endpoint    string  `yaml:"endpoint" default:"localhost:4318"` //host:port of OTLP/HTTP collector
+	Insecure    bool    `yaml:"insecure" default:"true"`           //use http instead of https
+	SampleRatio float64 `yaml:"sampleRatio" default:"1"`           //(0,1] ratio of new traces to sample, parent decision is respected
+	Sync        bool    `yaml:"sync" default:"false"`              //export spans when they end instead of in batches, for tests and debugging
+}
+
+// spanKey is the key of the request span in user values of fasthttp.RequestCtx
+type spanKey struct{}
+
+// span returns the span of current request, a no-op span if tracing is not used
+func (c *Context) span() trace.Span {
+	if span, ok := c.ctx.UserValue(spanKey{}).(trace.Span); ok {
+		return span
+	}
+	return noopSpan
+}
+
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// Span returns the span of current request started by the tracing middleware
+func (c *Context) Span() trace.Span {
+	return c.span()
+}
+
+// startPhase starts a child span of the request span for a phase of handling (bind, handler),
+// it returns a no-op span if tracing is not used
+func (c *Context) startPhase(name string) trace.Span {
+	parent := c.span()
+	if !parent.IsRecording() {
+		return noopSpan
+	}
+	_, span := parent.TracerProvider().Tracer(tracerName).Start(trace.ContextWithSpan(context.Background(), parent), name)
+	return span
+}
+
+// endPhase records err into span and ends it
+func endPhase(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// headerCarrier adapts fasthttp request header to propagation.TextMapCarrier
+type headerCarrier struct {
+	h *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.h.Peek(key))
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	c.h.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for key := range c.h.All() {
+		keys = append(keys, string(key))
+	}
+	return keys
+}
+
+// NewTracingMiddleware returns a global middleware which extracts W3C traceparent from requests
+// and starts a span for every request named by route pattern, with child spans for binding (and validation)
+// and the handler. use Context.Context to create child spans in services:
+//
+//	ctx, span := tracer.Start(c.Context(), "query users")
+//	defer span.End()
+//
+// spans are exported according to `tracing` in config, or to exporter if it is given (e.g. tracetest.NewInMemoryExporter for tests).
+// a given exporter receives spans synchronously when they end, so they can be checked right after a request.
+// the trace.Tracer and trace.TracerProvider will be mapped into the injector.
+func NewTracingMiddleware(exporter ...sdktrace.SpanExporter) IMiddlewareGlobal {
+	t := &TracingMiddleware{
+		MiddlewareGlobal: NewMiddlewareGlobal("Tracing"),
+	}
+	if len(exporter) > 0 {
+		t.exporter = exporter[0]
+	}
+	return t
+}
+
+type TracingMiddleware struct {
+	*MiddlewareGlobal
+	option     TracingOption
+	exporter   sdktrace.SpanExporter
+	provider   trace.TracerProvider
+	sdk        *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func (t *TracingMiddleware) DoInitOnce() {
+	t.option = TracingOption{Exporter: "none"}
+	s, _ := t.MiddlewareGlobal.provider.(*Server)
+	if s != nil && s.option != nil {
+		t.option = s.option.Tracing
+		if t.option.ServiceName == "" {
+			t.option.ServiceName = s.option.Name
+		}
+	}
+	t.propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	if err := t.initProvider(); err != nil {
+		panic(fmt.Sprintf("tracing: %v", err))
+	}
+	t.tracer = t.provider.Tracer(tracerName)
+	if s != nil {
+		s.MapTo(t.tracer, (*trace.Tracer)(nil))
+		s.MapTo(t.provider, (*trace.TracerProvider)(nil))
+		s.OnShutdown(t.Shutdown)
+	}
+}
+
+func (t *TracingMiddleware) initProvider() error {
+	exporter := t.exporter
+	// exporters given to NewTracingMiddleware are mostly used by tests which check spans after requests
+	syncer := exporter != nil || t.option.Sync
+	if exporter == nil {
+		switch strings.ToLower(t.option.Exporter) {
+		case "otlp":
+			opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(t.option.Endpoint)}
+			if t.option.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
+			var err error
+			exporter, err = otlptracehttp.New(context.Background(), opts...)
+			if err != nil {
+				return err
+			}
+		case "", "none":
+			t.provider = noop.NewTracerProvider()
+			return nil
+		default:
+			return fmt.Errorf("unknown exporter %q, should be otlp or none", t.option.Exporter)
+		}
+	}
+	t.exporter = exporter
+
+	sampler := sdktrace.AlwaysSample()
+	if t.option.SampleRatio > 0 && t.option.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(t.option.SampleRatio)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(t.option.ServiceName))),
+	}
+	if syncer {
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	} else {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	t.sdk = sdktrace.NewTracerProvider(opts...)
+	t.provider = t.sdk
+	return nil
+}
+
+// Exporter returns the span exporter, it is nil if tracing is disabled
+func (t *TracingMiddleware) Exporter() sdktrace.SpanExporter {
+	return t.exporter
+}
+
+// Shutdown flushes and stops exporting spans
+func (t *TracingMiddleware) Shutdown(ctx context.Context) error {
+	if t.sdk == nil {
+		return nil
+	}
+	return t.sdk.Shutdown(ctx)
+}
+
+// Priority makes the request span cover the other middlewares
+func (t *TracingMiddleware) Priority() int {
+	return -850
+}
+
+func (t *TracingMiddleware) Execute(ctx *MiddlewareContext) HandlerFunc {
+	if ctx.Ignored {
+		return ctx.Next
+	}
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if ctx.Controller != "" {
+		attrs = append(attrs, attribute.String("fw.controller", ctx.Controller))
+	}
+	if ctx.Handler != "" {
+		attrs = append(attrs, attribute.String("fw.handler", ctx.Handler))
+	}
+	return func(c *Context) {
+		parent := t.propagator.Extract(context.Background(), headerCarrier{h: &c.ctx.Request.Header})
+		method := string(c.ctx.Method())
+		route := c.RoutePattern()
+		name := method
+		if route != "" {
+			name += " " + route
+		}
+		_, span := t.tracer.Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(string(c.ctx.Path())),
+				semconv.ClientAddress(c.RemoteIP()),
+				semconv.UserAgentOriginal(string(c.ctx.UserAgent())),
+			))
+		if id := c.RequestID(); id != "" {
+			span.SetAttributes(attribute.String("fw.request_id", id))
+		}
+		c.ctx.SetUserValue(spanKey{}, span)
+		defer func() {
+			status := c.ctx.Response.StatusCode()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= 500 {
+				span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+			}
+			span.End()
+		}()
+		ctx.Next(c)
+	}
+}
@@ -0,0 +1,102 @@
+package fw
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/linxlib/fw/tracetest"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	s := newTestServer()
+	exporter := tracetest.NewInMemoryExporter()
+	s.Use(NewTracingMiddleware(exporter))
+	var tracer trace.Tracer
+	if err := s.Provide(&tracer); err != nil || tracer == nil {
+		t.Fatalf("Provide(Tracer) = %v", err)
+	}
+	Handle(s, "GET", "/users/{id}", func(c *Context, req *struct{}) (*testUser, error) {
+		_, span := tracer.Start(c.Context(), "query")
+		span.End()
+		if c.Param("id") == "0" {
+			return nil, errors.New("not found")
+		}
+		return &testUser{}, nil
+	})
+
+	tests := []struct {
+		name       string
+		uri        string
+		parent     string
+		wantStatus codes.Code
+	}{
+		{name: "ok", uri: "/users/1", wantStatus: codes.Unset},
+		{name: "parent", uri: "/users/1", parent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantStatus: codes.Unset},
+		{name: "error", uri: "/users/0", wantStatus: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("http://localhost" + tt.uri)
+			if tt.parent != "" {
+				ctx.Request.Header.Set("traceparent", tt.parent)
+			}
+			s.Handler()(ctx)
+
+			spans := exporter.GetSpans()
+			names := make(map[string]int)
+			for i, span := range spans {
+				names[span.Name] = i
+			}
+			for _, name := range []string{"bind", "handler", "query", "GET /users/{id}"} {
+				if _, ok := names[name]; !ok {
+					t.Fatalf("span %s not found in %v", name, names)
+				}
+			}
+			root := spans[names["GET /users/{id}"]]
+			if root.Status.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", root.Status.Code, tt.wantStatus)
+			}
+			if tt.parent != "" && root.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("parent = %v", root.Parent)
+			}
+			for _, name := range []string{"bind", "handler", "query"} {
+				if spans[names[name]].Parent.SpanID() != root.SpanContext.SpanID() {
+					t.Errorf("%s should be a child of request span", name)
+				}
+			}
+		})
+	}
+}
+
+func TestTracingMiddleware_initProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		option     TracingOption
+		wantExport bool
+		wantErr    bool
+	}{
+		{name: "default", option: TracingOption{}},
+		{name: "none", option: TracingOption{Exporter: "none"}},
+		{name: "otlp", option: TracingOption{Exporter: "otlp", Endpoint: "localhost:4318"}, wantExport: true},
+		{name: "unknown", option: TracingOption{Exporter: "memory"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &TracingMiddleware{option: tt.option}
+			err := m.initProvider()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("initProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (m.Exporter() != nil) != tt.wantExport {
+				t.Errorf("Exporter() = %v, wantExport %v", m.Exporter(), tt.wantExport)
+			}
+			_ = m.Shutdown(context.Background())
+		})
+	}
+}
@@ -93,6 +93,20 @@ func Stringify(v interface{}) string {
 	}
 	return ret
 }
+
+// isNilValue reports whether v is invalid or a nil pointer/interface/map/slice/func/chan.
+// unlike reflect.Value.IsNil, it will not panic for other kinds
+func isNilValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
 func IsObject(v interface{}) bool {
 	return reflect.ValueOf(v).Kind() == reflect.Struct
 }
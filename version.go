@@ -0,0 +1,55 @@
+package fw
+
+import (
+	"runtime/debug"
+)
+
+// build information of the app, set by `fw build` via
+//
+//	-ldflags "-X github.com/linxlib/fw.AppVersion=v1.2.3 -X github.com/linxlib/fw.Commit=abc1234 -X github.com/linxlib/fw.BuildTime=2024-01-01T00:00:00Z"
+var (
+	AppVersion string
+	Commit     string
+	BuildTime  string
+)
+
+// BuildInfo is the version information of the app
+type BuildInfo struct {
+	Version   string // version of the app, Version of fw when not stamped
+	Commit    string
+	BuildTime string
+}
+
+// GetBuildInfo returns the version information stamped by `fw build`.
+// commit and build time fall back to the vcs information recorded by go build.
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   AppVersion,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+	if info.Version == "" {
+		info.Version = Version
+	}
+	if info.Commit != "" && info.BuildTime != "" {
+		return info
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+					if len(info.Commit) > 7 {
+						info.Commit = info.Commit[:7]
+					}
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}